@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"web/engine"
+)
+
+// PrincipalKey is the Context key of the authenticated principal: the user
+// name for Basic auth, what the verifier returned for bearer tokens and
+// *Claims for JWT.
+const PrincipalKey = "auth.principal"
+
+// Principal returns the principal stored by one of the middlewares, or nil.
+func Principal(c *engine.Context) interface{} {
+	principal, _ := c.Get(PrincipalKey)
+	return principal
+}
+
+// unauthorized answers 401 with a challenge, see RFC 7235 section 4.1.
+func unauthorized(c *engine.Context, scheme string, params ...string) {
+	challenge := scheme
+	for i := 0; i+1 < len(params); i += 2 {
+		if i == 0 {
+			challenge += " "
+		} else {
+			challenge += ", "
+		}
+		challenge += params[i] + "=" + strconv.Quote(params[i+1])
+	}
+	c.SetHeader("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, engine.H{"error": "unauthorized"})
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web/engine"
+)
+
+func serve(e *engine.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func whoami(c *engine.Context) {
+	c.String(http.StatusOK, "%v", Principal(c))
+}
+
+func TestBasicAuth(t *testing.T) {
+	e := engine.New()
+	admin := e.Group("/admin")
+	admin.Use(BasicAuth(Accounts{"geek": "secret"}))
+	admin.Get("/me", whoami)
+	e.Get("/custom", BasicAuthWithConfig(BasicConfig{
+		Realm: "custom",
+		Verify: func(c *engine.Context, username, password string) bool {
+			return username == "root" && password == "toor"
+		},
+	}), whoami)
+
+	basic := func(username, password string) map[string]string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		return map[string]string{"Authorization": req.Header.Get("Authorization")}
+	}
+
+	if w := serve(e, "/admin/me", basic("geek", "secret")); w.Code != http.StatusOK || w.Body.String() != "geek" {
+		t.Fatalf("expect geek, got %d %q", w.Code, w.Body.String())
+	}
+	for _, header := range []map[string]string{nil, basic("geek", "wrong"), basic("nobody", "")} {
+		w := serve(e, "/admin/me", header)
+		if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Basic realm="Authorization Required", charset="UTF-8"` {
+			t.Fatalf("expect a challenge, got %d %v", w.Code, w.Header())
+		}
+	}
+
+	if w := serve(e, "/custom", basic("root", "toor")); w.Code != http.StatusOK || w.Body.String() != "root" {
+		t.Fatalf("expect root, got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(e, "/custom", basic("geek", "secret")); w.Header().Get("WWW-Authenticate") != `Basic realm="custom", charset="UTF-8"` {
+		t.Fatalf("expect the custom realm, got %v", w.Header())
+	}
+}
+
+func TestBearer(t *testing.T) {
+	e := engine.New()
+	e.Use(Bearer(func(c *engine.Context, token string) (interface{}, error) {
+		if token != "t0k3n" {
+			return nil, errors.New("unknown token")
+		}
+		return "service", nil
+	}))
+	e.Get("/me", whoami)
+
+	if w := serve(e, "/me", map[string]string{"Authorization": "bearer t0k3n"}); w.Code != http.StatusOK || w.Body.String() != "service" {
+		t.Fatalf("expect service, got %d %q", w.Code, w.Body.String())
+	}
+	if w := serve(e, "/me", nil); w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer realm="api"` {
+		t.Fatalf("expect a challenge, got %d %v", w.Code, w.Header())
+	}
+	w := serve(e, "/me", map[string]string{"Authorization": "Bearer nope"})
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer realm="api", error="invalid_token"` {
+		t.Fatalf("expect invalid_token, got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"web/engine"
+)
+
+// Accounts maps user names to passwords.
+type Accounts map[string]string
+
+type BasicConfig struct {
+	Accounts Accounts
+	// Verify is used instead of Accounts, e.g. to check a password hash.
+	Verify func(c *engine.Context, username, password string) bool
+	// Realm defaults to "Authorization Required".
+	Realm string
+}
+
+func BasicAuth(accounts Accounts) engine.HandlerFunc {
+	return BasicAuthWithConfig(BasicConfig{Accounts: accounts})
+}
+
+// BasicAuthWithConfig checks HTTP Basic credentials (RFC 7617) and stores
+// the user name under PrincipalKey.
+func BasicAuthWithConfig(conf BasicConfig) engine.HandlerFunc {
+	realm := conf.Realm
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	verify := conf.Verify
+	if verify == nil {
+		if len(conf.Accounts) == 0 {
+			panic("auth: basic auth needs accounts or a verify func")
+		}
+		verify = conf.Accounts.verify
+	}
+
+	return func(c *engine.Context) {
+		username, password, ok := c.Req.BasicAuth()
+		if !ok || !verify(c, username, password) {
+			unauthorized(c, "Basic", "realm", realm, "charset", "UTF-8")
+			return
+		}
+		c.Set(PrincipalKey, username)
+		c.Next()
+	}
+}
+
+// verify compares hashes so that the time taken does not depend on the
+// password length, nor on whether the user exists.
+func (accounts Accounts) verify(c *engine.Context, username, password string) bool {
+	expected, ok := accounts[username]
+	a := sha256.Sum256([]byte(password))
+	b := sha256.Sum256([]byte(expected))
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1 && ok
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"strings"
+
+	"web/engine"
+)
+
+// TokenVerifier checks a bearer token and returns its principal.
+type TokenVerifier func(c *engine.Context, token string) (principal interface{}, err error)
+
+type BearerConfig struct {
+	Verify TokenVerifier
+	Realm  string
+}
+
+func Bearer(verify TokenVerifier) engine.HandlerFunc {
+	return BearerWithConfig(BearerConfig{Verify: verify})
+}
+
+// BearerWithConfig reads the token from the Authorization header (RFC 6750)
+// and stores the principal returned by Verify under PrincipalKey.
+func BearerWithConfig(conf BearerConfig) engine.HandlerFunc {
+	if conf.Verify == nil {
+		panic("auth: bearer auth needs a verify func")
+	}
+	realm := conf.Realm
+	if realm == "" {
+		realm = "api"
+	}
+
+	return func(c *engine.Context) {
+		token, ok := BearerToken(c)
+		if !ok {
+			unauthorized(c, "Bearer", "realm", realm)
+			return
+		}
+		principal, err := conf.Verify(c, token)
+		if err != nil {
+			unauthorized(c, "Bearer", "realm", realm, "error", "invalid_token")
+			return
+		}
+		c.Set(PrincipalKey, principal)
+		c.Next()
+	}
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header.
+func BearerToken(c *engine.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
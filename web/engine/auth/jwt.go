@@ -0,0 +1,344 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"web/engine"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+var (
+	ErrTokenMalformed   = errors.New("auth: malformed token")
+	ErrTokenAlgorithm   = errors.New("auth: unexpected signing algorithm")
+	ErrTokenUnknownKey  = errors.New("auth: unknown signing key")
+	ErrTokenSignature   = errors.New("auth: invalid token signature")
+	ErrTokenExpired     = errors.New("auth: token is expired")
+	ErrTokenNotValidYet = errors.New("auth: token is not valid yet")
+	ErrTokenIssuer      = errors.New("auth: unexpected token issuer")
+	ErrTokenAudience    = errors.New("auth: unexpected token audience")
+)
+
+// Claims are the registered claims of a JWT (RFC 7519 section 4.1). Raw
+// holds every claim, including private ones.
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	Raw       map[string]interface{}
+}
+
+// GetClaims returns the claims stored by the JWT middleware.
+func GetClaims(c *engine.Context) (*Claims, bool) {
+	claims, ok := Principal(c).(*Claims)
+	return claims, ok
+}
+
+type JWTConfig struct {
+	// Keys maps key ids to verification keys: []byte for HS256,
+	// *rsa.PublicKey for RS256 and *ecdsa.PublicKey (P-256) for ES256.
+	// Tokens without a kid use the key with the empty id. To rotate keys,
+	// add the new kid before signing with it and remove the old one once
+	// its tokens expired.
+	Keys map[string]interface{}
+	// KeyFunc looks keys up instead of Keys, e.g. from a JWKS endpoint.
+	KeyFunc func(kid string) (interface{}, error)
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// Leeway is the clock skew tolerated when checking exp and nbf.
+	Leeway time.Duration
+	Realm  string
+}
+
+// JWTVerifier checks tokens outside of the middleware, e.g. one sent in a
+// WebSocket message.
+type JWTVerifier struct {
+	conf JWTConfig
+}
+
+func NewJWTVerifier(conf JWTConfig) *JWTVerifier {
+	if conf.KeyFunc == nil && len(conf.Keys) == 0 {
+		panic("auth: jwt needs keys or a key func")
+	}
+	return &JWTVerifier{conf: conf}
+}
+
+// JWT verifies bearer tokens and stores their *Claims under PrincipalKey.
+func JWT(conf JWTConfig) engine.HandlerFunc {
+	v := NewJWTVerifier(conf)
+	return BearerWithConfig(BearerConfig{
+		Realm: conf.Realm,
+		Verify: func(c *engine.Context, token string) (interface{}, error) {
+			return v.Verify(token)
+		},
+	})
+}
+
+type jwtHeader struct {
+	Alg  string   `json:"alg"`
+	Kid  string   `json:"kid,omitempty"`
+	Typ  string   `json:"typ,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+// Verify checks the signature and the time, issuer and audience claims.
+func (v *JWTVerifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	// no extension is understood (RFC 7515 section 4.1.11)
+	if len(header.Crit) > 0 {
+		return nil, ErrTokenMalformed
+	}
+
+	key, err := v.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	claims, err := parseClaims(raw)
+	if err != nil {
+		return nil, err
+	}
+	return claims, v.validate(claims)
+}
+
+func (v *JWTVerifier) key(kid string) (interface{}, error) {
+	if v.conf.KeyFunc != nil {
+		key, err := v.conf.KeyFunc(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, ErrTokenUnknownKey
+		}
+		return key, nil
+	}
+	key, ok := v.conf.Keys[kid]
+	if !ok {
+		return nil, ErrTokenUnknownKey
+	}
+	return key, nil
+}
+
+func (v *JWTVerifier) validate(claims *Claims) error {
+	t := now()
+	leeway := v.conf.Leeway
+	if !claims.ExpiresAt.IsZero() && !t.Before(claims.ExpiresAt.Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if !claims.NotBefore.IsZero() && t.Before(claims.NotBefore.Add(-leeway)) {
+		return ErrTokenNotValidYet
+	}
+	if v.conf.Issuer != "" && claims.Issuer != v.conf.Issuer {
+		return ErrTokenIssuer
+	}
+	if v.conf.Audience != "" {
+		for _, aud := range claims.Audience {
+			if aud == v.conf.Audience {
+				return nil
+			}
+		}
+		return ErrTokenAudience
+	}
+	return nil
+}
+
+// verifySignature checks that the algorithm is the one of the key type, so
+// that a public key can not be used as an HMAC secret.
+func verifySignature(alg string, key interface{}, input string, signature []byte) error {
+	digest := sha256.Sum256([]byte(input))
+	switch k := key.(type) {
+	case []byte:
+		if alg != "HS256" {
+			return ErrTokenAlgorithm
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+	case *rsa.PublicKey:
+		if alg != "RS256" {
+			return ErrTokenAlgorithm
+		}
+		if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) != nil {
+			return ErrTokenSignature
+		}
+	case *ecdsa.PublicKey:
+		if alg != "ES256" || k.Curve != elliptic.P256() {
+			return ErrTokenAlgorithm
+		}
+		// the signature is r and s, 32 bytes each (RFC 7518 section 3.4)
+		if len(signature) != 64 {
+			return ErrTokenSignature
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(k, digest[:], r, s) {
+			return ErrTokenSignature
+		}
+	default:
+		return ErrTokenAlgorithm
+	}
+	return nil
+}
+
+func parseClaims(raw map[string]interface{}) (*Claims, error) {
+	claims := &Claims{Raw: raw}
+	var ok bool
+	for name, value := range raw {
+		switch name {
+		case "iss":
+			claims.Issuer, ok = value.(string)
+		case "sub":
+			claims.Subject, ok = value.(string)
+		case "jti":
+			claims.ID, ok = value.(string)
+		case "aud":
+			claims.Audience, ok = audience(value)
+		case "exp":
+			claims.ExpiresAt, ok = numericDate(value)
+		case "nbf":
+			claims.NotBefore, ok = numericDate(value)
+		case "iat":
+			claims.IssuedAt, ok = numericDate(value)
+		default:
+			continue
+		}
+		if !ok {
+			return nil, ErrTokenMalformed
+		}
+	}
+	return claims, nil
+}
+
+// audience accepts a single string or an array of strings.
+func audience(value interface{}) ([]string, bool) {
+	switch aud := value.(type) {
+	case string:
+		return []string{aud}, true
+	case []interface{}:
+		list := make([]string, 0, len(aud))
+		for _, item := range aud {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, s)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// numericDate reads seconds since the epoch, possibly fractional.
+func numericDate(value interface{}) (time.Time, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second))), true
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// SignJWT signs claims, any value encoding to a JSON object, for issuing
+// tokens. The algorithm follows the key: []byte for HS256, *rsa.PrivateKey
+// for RS256 and *ecdsa.PrivateKey for ES256.
+func SignJWT(claims interface{}, kid string, key interface{}) (string, error) {
+	header := jwtHeader{Kid: kid, Typ: "JWT"}
+	switch key.(type) {
+	case []byte:
+		header.Alg = "HS256"
+	case *rsa.PrivateKey:
+		header.Alg = "RS256"
+	case *ecdsa.PrivateKey:
+		header.Alg = "ES256"
+	default:
+		return "", ErrTokenAlgorithm
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			return "", err
+		}
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", ErrTokenAlgorithm
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return "", err
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
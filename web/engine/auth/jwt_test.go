@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"web/engine"
+)
+
+func setClock(t *testing.T, clock time.Time) {
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func sign(t *testing.T, claims map[string]interface{}, kid string, key interface{}) string {
+	t.Helper()
+	token, err := SignJWT(claims, kid, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+
+	v := NewJWTVerifier(JWTConfig{Keys: map[string]interface{}{
+		"hs": secret,
+		"rs": &rsaKey.PublicKey,
+		"es": &ecKey.PublicKey,
+	}})
+	claims := map[string]interface{}{"sub": "geek", "aud": []string{"api", "web"}, "role": "admin"}
+	for kid, key := range map[string]interface{}{"hs": secret, "rs": rsaKey, "es": ecKey} {
+		got, err := v.Verify(sign(t, claims, kid, key))
+		if err != nil {
+			t.Fatalf("%s: %v", kid, err)
+		}
+		if got.Subject != "geek" || len(got.Audience) != 2 || got.Raw["role"] != "admin" {
+			t.Fatalf("%s: unexpected claims %+v", kid, got)
+		}
+	}
+
+	token := sign(t, claims, "hs", secret)
+	if _, err := v.Verify(token[:len(token)-2] + "xx"); err != ErrTokenSignature {
+		t.Fatalf("expect a signature error, got %v", err)
+	}
+	if _, err := v.Verify(sign(t, claims, "hs", []byte("other"))); err != ErrTokenSignature {
+		t.Fatalf("expect a signature error for another secret, got %v", err)
+	}
+	if _, err := v.Verify(sign(t, claims, "old", secret)); err != ErrTokenUnknownKey {
+		t.Fatalf("expect an unknown key, got %v", err)
+	}
+	// an HS256 token for an RSA kid must not verify with the public key
+	if _, err := v.Verify(sign(t, claims, "rs", secret)); err != ErrTokenAlgorithm {
+		t.Fatalf("expect an algorithm error, got %v", err)
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"hs"}`)) + "." +
+		strings.Split(token, ".")[1] + "."
+	if _, err := v.Verify(none); err != ErrTokenAlgorithm {
+		t.Fatalf("unsigned tokens must be rejected, got %v", err)
+	}
+}
+
+func TestJWTClaims(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	setClock(t, clock)
+	secret := []byte("secret")
+	v := NewJWTVerifier(JWTConfig{
+		Keys:     map[string]interface{}{"": secret},
+		Issuer:   "gee",
+		Audience: "api",
+		Leeway:   30 * time.Second,
+	})
+
+	base := func(extra map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{"iss": "gee", "aud": "api"}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		return claims
+	}
+	unix := func(d time.Duration) int64 { return clock.Add(d).Unix() }
+
+	for _, tc := range []struct {
+		name   string
+		claims map[string]interface{}
+		err    error
+	}{
+		{"valid", base(map[string]interface{}{"exp": unix(time.Hour), "nbf": unix(-time.Hour)}), nil},
+		{"expired within leeway", base(map[string]interface{}{"exp": unix(-10 * time.Second)}), nil},
+		{"expired", base(map[string]interface{}{"exp": unix(-time.Minute)}), ErrTokenExpired},
+		{"not yet valid within leeway", base(map[string]interface{}{"nbf": unix(10 * time.Second)}), nil},
+		{"not yet valid", base(map[string]interface{}{"nbf": unix(time.Minute)}), ErrTokenNotValidYet},
+		{"issuer", map[string]interface{}{"iss": "other", "aud": "api"}, ErrTokenIssuer},
+		{"audience", map[string]interface{}{"iss": "gee", "aud": []string{"web"}}, ErrTokenAudience},
+		{"malformed exp", base(map[string]interface{}{"exp": "tomorrow"}), ErrTokenMalformed},
+	} {
+		if _, err := v.Verify(sign(t, tc.claims, "", secret)); err != tc.err {
+			t.Fatalf("%s: expect %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	oldKey, newKey := []byte("old"), []byte("new")
+	e := engine.New()
+	e.Use(JWT(JWTConfig{Keys: map[string]interface{}{"2023": oldKey, "2024": newKey}}))
+	e.Get("/me", func(c *engine.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, claims.Subject)
+	})
+
+	// tokens signed before and after the rotation are both accepted
+	for kid, key := range map[string][]byte{"2023": oldKey, "2024": newKey} {
+		token := sign(t, map[string]interface{}{"sub": "geek"}, kid, key)
+		w := serve(e, "/me", map[string]string{"Authorization": "Bearer " + token})
+		if w.Code != http.StatusOK || w.Body.String() != "geek" {
+			t.Fatalf("kid %s: expect geek, got %d %q", kid, w.Code, w.Body.String())
+		}
+	}
+
+	w := serve(e, "/me", map[string]string{"Authorization": "Bearer not.a.token"})
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Header().Get("WWW-Authenticate"), "invalid_token") {
+		t.Fatalf("expect 401, got %d %v", w.Code, w.Header())
+	}
+}
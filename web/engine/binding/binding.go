@@ -0,0 +1,87 @@
+package binding
+
+import (
+	"net/http"
+
+	"web/engine/validate"
+)
+
+const (
+	MIMEJSON              = "application/json"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEHTML              = "text/html"
+	MIMEPlain             = "text/plain"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEYAML              = "application/yaml"
+	MIMEMSGPACK           = "application/msgpack"
+)
+
+// defaultMemory is the number of bytes of a multipart body kept in memory,
+// the rest is stored in temporary files.
+const defaultMemory = 32 << 20
+
+// Binding decodes a request into obj, which must be a pointer.
+type Binding interface {
+	Name() string
+	Bind(*http.Request, interface{}) error
+}
+
+// URIBinding decodes route params into obj.
+type URIBinding interface {
+	Name() string
+	BindURI(map[string][]string, interface{}) error
+}
+
+// BindUnmarshaler lets a type decode itself from a single form, query, uri
+// or header value.
+type BindUnmarshaler interface {
+	UnmarshalParam(param string) error
+}
+
+// StructValidator checks a struct once it has been bound.
+type StructValidator interface {
+	ValidateStruct(interface{}) error
+}
+
+// Validator runs after every successful bind; set it to nil to disable
+// validation.
+var Validator StructValidator = validate.Default
+
+func validateStruct(obj interface{}) error {
+	if Validator == nil {
+		return nil
+	}
+	return Validator.ValidateStruct(obj)
+}
+
+var (
+	JSON          = jsonBinding{}
+	XML           = xmlBinding{}
+	Form          = formBinding{}
+	Query         = queryBinding{}
+	FormPost      = formPostBinding{}
+	FormMultipart = formMultipartBinding{}
+	Header        = headerBinding{}
+	URI           = uriBinding{}
+)
+
+// Default picks a binding from the request method and content type.
+func Default(method, contentType string) Binding {
+	if method == http.MethodGet {
+		return Form
+	}
+
+	switch contentType {
+	case MIMEJSON:
+		return JSON
+	case MIMEXML, MIMEXML2:
+		return XML
+	case MIMEMultipartPOSTForm:
+		return FormMultipart
+	default:
+		return Form
+	}
+}
@@ -0,0 +1,117 @@
+package binding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type upperString string
+
+func (s *upperString) UnmarshalParam(param string) error {
+	*s = upperString(strings.ToUpper(param))
+	return nil
+}
+
+type address struct {
+	City string `form:"city"`
+}
+
+type profile struct {
+	Name     string        `form:"name"`
+	Age      *int          `form:"age"`
+	Tags     []string      `form:"tag"`
+	Page     int           `form:"page,default=1"`
+	Birthday time.Time     `form:"birthday" time_format:"2006-01-02" time_utc:"1"`
+	Timeout  time.Duration `form:"timeout"`
+	Code     upperString   `form:"code"`
+	Skip     string        `form:"-"`
+	Address  address
+}
+
+func TestFormBinding(t *testing.T) {
+	body := "name=geek&age=18&tag=a&tag=b&birthday=2000-01-02&timeout=3s&code=abc&city=shanghai&Skip=x"
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+
+	var p profile
+	if err := Default(req.Method, MIMEPOSTForm).Bind(req, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "geek" || p.Age == nil || *p.Age != 18 || len(p.Tags) != 2 || p.Page != 1 {
+		t.Fatalf("unexpected basic fields %+v", p)
+	}
+	if !p.Birthday.Equal(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)) || p.Timeout != 3*time.Second {
+		t.Fatalf("unexpected time fields %+v", p)
+	}
+	if p.Code != "ABC" || p.Skip != "" || p.Address.City != "shanghai" {
+		t.Fatalf("unexpected custom fields %+v", p)
+	}
+}
+
+func TestFormBindingInvalid(t *testing.T) {
+	var p profile
+	if err := MapForm(&p, map[string][]string{"age": {"x"}}); err == nil {
+		t.Fatalf("invalid int should fail")
+	}
+}
+
+type node struct {
+	Name string `form:"name"`
+	Next *node
+}
+
+func TestFormBindingRecursive(t *testing.T) {
+	var n node
+	if err := MapForm(&n, map[string][]string{"name": {"head"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n.Name != "head" || n.Next != nil {
+		t.Fatalf("unexpected node %+v", n)
+	}
+}
+
+func TestHeaderAndURIBinding(t *testing.T) {
+	var h struct {
+		RequestID string `header:"x-request-id"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "42")
+	if err := Header.Bind(req, &h); err != nil || h.RequestID != "42" {
+		t.Fatalf("header binding failed: %v %+v", err, h)
+	}
+
+	var u struct {
+		ID uint `uri:"id"`
+	}
+	if err := URI.BindURI(map[string][]string{"id": {"7"}}, &u); err != nil || u.ID != 7 {
+		t.Fatalf("uri binding failed: %v %+v", err, u)
+	}
+}
+
+func TestMultipartBinding(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", "geek")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var form struct {
+		Name   string                `form:"name"`
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}
+	if err := FormMultipart.Bind(req, &form); err != nil {
+		t.Fatal(err)
+	}
+	if form.Name != "geek" || form.Avatar == nil || form.Avatar.Filename != "avatar.png" {
+		t.Fatalf("unexpected multipart form %+v", form)
+	}
+}
@@ -0,0 +1,93 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+)
+
+type formBinding struct{}
+type queryBinding struct{}
+type formPostBinding struct{}
+type formMultipartBinding struct{}
+type headerBinding struct{}
+type uriBinding struct{}
+
+func (formBinding) Name() string {
+	return "form"
+}
+
+// Bind reads the query string and the body, whether urlencoded or multipart.
+func (formBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	var src source = formSource(req.Form)
+	if req.MultipartForm != nil {
+		src = multipartSource{formSource(req.Form), req.MultipartForm.File}
+	}
+	if err := mapWith(obj, src, "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+func (queryBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := mapWith(obj, formSource(req.URL.Query()), "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+func (formPostBinding) Name() string {
+	return "form-urlencoded"
+}
+
+func (formPostBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	if err := mapWith(obj, formSource(req.PostForm), "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+func (formMultipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+func (formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(defaultMemory); err != nil {
+		return err
+	}
+	if err := mapWith(obj, multipartSource{formSource(req.MultipartForm.Value), req.MultipartForm.File}, "form"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+func (headerBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := mapWith(obj, headerSource(req.Header), "header"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+func (uriBinding) Name() string {
+	return "uri"
+}
+
+func (uriBinding) BindURI(params map[string][]string, obj interface{}) error {
+	if err := mapWith(obj, formSource(params), "uri"); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
@@ -0,0 +1,23 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("binding: invalid request")
+	}
+	if err := json.NewDecoder(req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
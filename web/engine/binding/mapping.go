@@ -0,0 +1,348 @@
+package binding
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+)
+
+// source looks up the raw values for a key.
+type source interface {
+	values(key string) ([]string, bool)
+}
+
+type fileSource interface {
+	files(key string) ([]*multipart.FileHeader, bool)
+}
+
+type formSource map[string][]string
+
+func (form formSource) values(key string) ([]string, bool) {
+	vs, ok := form[key]
+	return vs, ok
+}
+
+type headerSource http.Header
+
+func (header headerSource) values(key string) ([]string, bool) {
+	vs, ok := header[textproto.CanonicalMIMEHeaderKey(key)]
+	return vs, ok
+}
+
+type multipartSource struct {
+	formSource
+	fileHeaders map[string][]*multipart.FileHeader
+}
+
+func (m multipartSource) files(key string) ([]*multipart.FileHeader, bool) {
+	fhs, ok := m.fileHeaders[key]
+	return fhs, ok
+}
+
+// MapForm fills the struct pointed to by ptr from form values using the
+// "form" tag.
+func MapForm(ptr interface{}, form map[string][]string) error {
+	return mapWith(ptr, formSource(form), "form")
+}
+
+func mapWith(ptr interface{}, src source, tag string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("binding: obj must be a non-nil pointer")
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return errors.New("binding: obj must point to a struct")
+	}
+	_, err := mapStruct(v, src, tag, make(map[reflect.Type]bool))
+	return err
+}
+
+// mapStruct sets the fields of v and reports whether any of them was set.
+// visiting holds the struct types being mapped further up, so that
+// self-referential types such as struct{ Next *Node } are entered only once.
+func mapStruct(v reflect.Value, src source, tag string, visiting map[reflect.Type]bool) (bool, error) {
+	t := v.Type()
+	if visiting[t] {
+		return false, nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	isSet := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		ok, err := mapField(v.Field(i), field, src, tag, visiting)
+		if err != nil {
+			return false, err
+		}
+		isSet = isSet || ok
+	}
+	return isSet, nil
+}
+
+func mapField(value reflect.Value, field reflect.StructField, src source, tag string, visiting map[reflect.Type]bool) (bool, error) {
+	name, defaultValue := parseTag(field.Tag.Get(tag))
+	if name == "-" || !value.CanSet() {
+		return false, nil
+	}
+	if name == "" {
+		name = field.Name
+	}
+
+	if fs, ok := src.(fileSource); ok && isFileField(value.Type()) {
+		return setFiles(value, fs, name)
+	}
+
+	if value.Kind() == reflect.Ptr {
+		target := value
+		isNew := value.IsNil()
+		if isNew {
+			target = reflect.New(value.Type().Elem())
+		}
+		ok, err := mapField(target.Elem(), field, src, tag, visiting)
+		if err != nil {
+			return false, err
+		}
+		if isNew && ok {
+			value.Set(target)
+		}
+		return ok, nil
+	}
+
+	if value.Kind() == reflect.Struct && !isScalar(value) {
+		return mapStruct(value, src, tag, visiting)
+	}
+
+	vs, ok := src.values(name)
+	if !ok && defaultValue != "" {
+		vs, ok = []string{defaultValue}, true
+	}
+	if !ok {
+		return false, nil
+	}
+
+	switch value.Kind() {
+	case reflect.Slice:
+		if isScalar(value) {
+			break
+		}
+		slice := reflect.MakeSlice(value.Type(), len(vs), len(vs))
+		for i, s := range vs {
+			if err := setValue(slice.Index(i), field, s); err != nil {
+				return false, err
+			}
+		}
+		value.Set(slice)
+		return true, nil
+	case reflect.Array:
+		if len(vs) != value.Len() {
+			return false, fmt.Errorf("binding: %q is not valid value for %s", vs, value.Type())
+		}
+		for i, s := range vs {
+			if err := setValue(value.Index(i), field, s); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	if len(vs) == 0 {
+		return false, nil
+	}
+	return true, setValue(value, field, vs[0])
+}
+
+func setValue(value reflect.Value, field reflect.StructField, s string) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setValue(value.Elem(), field, s)
+	}
+
+	if value.Type() == timeType {
+		return setTime(value, field, s)
+	}
+	if ok, err := unmarshal(value, s); ok {
+		return err
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fieldError(field, s, err)
+			}
+			value.SetInt(int64(d))
+			return nil
+		}
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return fieldError(field, s, err)
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			s = "0"
+		}
+		n, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return fieldError(field, s, err)
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			s = "0"
+		}
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return fieldError(field, s, err)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		if s == "" {
+			s = "false"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fieldError(field, s, err)
+		}
+		value.SetBool(b)
+	case reflect.String:
+		value.SetString(s)
+	default:
+		return fmt.Errorf("binding: unsupported type %s for field %s", value.Type(), field.Name)
+	}
+	return nil
+}
+
+// setTime parses s using the time_format tag, which defaults to RFC3339 and
+// also accepts unix, unixmilli and unixnano. time_utc and time_location
+// choose the location of layouts without a zone.
+func setTime(value reflect.Value, field reflect.StructField, s string) error {
+	if s == "" {
+		value.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+
+	format := field.Tag.Get("time_format")
+	if format == "" {
+		format = time.RFC3339
+	}
+
+	switch format {
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fieldError(field, s, err)
+		}
+		var t time.Time
+		switch format {
+		case "unix":
+			t = time.Unix(n, 0)
+		case "unixmilli":
+			t = time.UnixMilli(n)
+		default:
+			t = time.Unix(0, n)
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	loc := time.Local
+	if utc, _ := strconv.ParseBool(field.Tag.Get("time_utc")); utc {
+		loc = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+
+	t, err := time.ParseInLocation(format, s, loc)
+	if err != nil {
+		return fieldError(field, s, err)
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func unmarshal(value reflect.Value, s string) (bool, error) {
+	if !value.CanAddr() {
+		return false, nil
+	}
+	switch u := value.Addr().Interface().(type) {
+	case BindUnmarshaler:
+		return true, u.UnmarshalParam(s)
+	case encoding.TextUnmarshaler:
+		return true, u.UnmarshalText([]byte(s))
+	}
+	return false, nil
+}
+
+// isScalar reports whether value is set from a single raw value even though
+// its kind is a struct or slice, such as time.Time or a custom unmarshaler.
+func isScalar(value reflect.Value) bool {
+	if value.Type() == timeType {
+		return true
+	}
+	t := reflect.PointerTo(value.Type())
+	return t.Implements(reflect.TypeOf((*BindUnmarshaler)(nil)).Elem()) ||
+		t.Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+func setFiles(value reflect.Value, fs fileSource, name string) (bool, error) {
+	fhs, ok := fs.files(name)
+	if !ok || len(fhs) == 0 {
+		return false, nil
+	}
+	if value.Kind() == reflect.Slice {
+		value.Set(reflect.ValueOf(fhs))
+	} else {
+		value.Set(reflect.ValueOf(fhs[0]))
+	}
+	return true, nil
+}
+
+func parseTag(tag string) (name string, defaultValue string) {
+	name, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if k, v, ok := strings.Cut(opt, "="); ok && k == "default" {
+			defaultValue = v
+		}
+	}
+	return name, defaultValue
+}
+
+func fieldError(field reflect.StructField, s string, err error) error {
+	return fmt.Errorf("binding: invalid value %q for field %s: %w", s, field.Name, err)
+}
@@ -1,54 +1,150 @@
 package engine
 
 import (
-	"encoding/json"
-	"fmt"
+	"net"
 	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
 
-	"github.com/julienschmidt/httprouter"
+	"web/engine/binding"
 )
 
 type H map[string]interface{}
 
 type Context struct {
 	// origin objects
-	Res http.ResponseWriter
+	Res ResponseWriter
 	Req *http.Request
 	// request info
-	Path   string
-	Method string
-	// response info
+	Path     string
+	Method   string
+	Params   Params
+	fullPath string
+	engine   *Engine
+	// response info, StatusCode is 200 until set with Status while
+	// Res.Status() also sees codes written to Res directly
 	StatusCode int
 	// middleware
 	handlers []HandlerFunc
 	index    int
+	aborted  bool
+	// per route overrides of the engine multipart limits
+	maxMultipartMemory int64
+	maxMultipartSize   int64
+	// request-scoped values, guarded by mu
+	Keys map[string]interface{}
+	mu   sync.RWMutex
 }
 
 func newContext(res http.ResponseWriter, req *http.Request) *Context {
 	return &Context{
-		Res:    res,
-		Req:    req,
-		Path:   req.URL.Path,
-		Method: req.Method,
-		index:  -1,
+		Res:        newResponseWriter(res),
+		Req:        req,
+		Path:       req.URL.Path,
+		Method:     req.Method,
+		StatusCode: http.StatusOK,
+		index:      -1,
 	}
 }
 
 func (c *Context) Next() {
 	c.index++
 	s := len(c.handlers)
-	for ; c.index < s; c.index++ {
+	for ; c.index < s && !c.aborted; c.index++ {
 		c.handlers[c.index](c)
 	}
 }
 
+// Copy returns a copy of c to use from another goroutine. It continues the
+// same handler chain and gets its own copy of Keys.
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Res:                c.Res,
+		Req:                c.Req,
+		Path:               c.Path,
+		Method:             c.Method,
+		Params:             append(Params(nil), c.Params...),
+		fullPath:           c.fullPath,
+		StatusCode:         c.StatusCode,
+		engine:             c.engine,
+		handlers:           c.handlers,
+		index:              c.index,
+		aborted:            c.aborted,
+		maxMultipartMemory: c.maxMultipartMemory,
+		maxMultipartSize:   c.maxMultipartSize,
+	}
+	c.mu.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]interface{}, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
+// Abort prevents the remaining handlers in the chain from being called.
+// The handler calling Abort still runs to completion.
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
+// HandlerName returns the function name of the handler currently running.
+func (c *Context) HandlerName() string {
+	if c.index < 0 || c.index >= len(c.handlers) {
+		return ""
+	}
+	return nameOfFunction(c.handlers[c.index])
+}
+
+// HandlerNames returns the function names of the whole chain, in order.
+func (c *Context) HandlerNames() []string {
+	names := make([]string, 0, len(c.handlers))
+	for _, handler := range c.handlers {
+		names = append(names, nameOfFunction(handler))
+	}
+	return names
+}
+
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
 func (c *Context) Param(key string) string {
-	params := httprouter.ParamsFromContext(c.Req.Context())
-	value := params.ByName(key)
-	return value
+	return c.Params.ByName(key)
 }
 
+// FullPath returns the registered route pattern, such as "/blogs/:id".
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+// PostForm returns the first value for key from the body or the query.
+// Multipart bodies are parsed by MultipartForm, an empty string is returned
+// when that fails.
 func (c *Context) PostForm(key string) string {
+	if c.ContentType() == binding.MIMEMultipartPOSTForm {
+		if _, err := c.MultipartForm(); err != nil {
+			return ""
+		}
+	}
 	return c.Req.FormValue(key)
 }
 
@@ -56,8 +152,12 @@ func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// Status sets the response status, which is sent with the first write.
 func (c *Context) Status(code int) {
-	c.StatusCode = code
+	// like the ResponseWriter, ignore codes that will not be sent
+	if code > 0 && !c.Res.Written() {
+		c.StatusCode = code
+	}
 	c.Res.WriteHeader(code)
 }
 
@@ -65,34 +165,17 @@ func (c *Context) SetHeader(key string, value string) {
 	c.Res.Header().Set(key, value)
 }
 
-func (c *Context) String(code int, format string, values ...interface{}) {
-	c.SetHeader("Content-Type", "text/plain")
-	c.Status(code)
-	c.Res.Write([]byte(fmt.Sprintf(format, values...)))
-}
-
-func (c *Context) JSON(code int, obj interface{}) {
-	c.SetHeader("Content-Type", "application/json")
-	c.Status(code)
-	encoder := json.NewEncoder(c.Res)
-	if err := encoder.Encode(obj); err != nil {
-		http.Error(c.Res, err.Error(), 500)
-	}
-}
-
-func (c *Context) Data(code int, data []byte) {
-	c.Status(code)
-	c.Res.Write(data)
-}
-
-func (c *Context) HTML(code int, html string) {
+func (c *Context) Fail(code int, html string) {
 	c.SetHeader("Content-Type", "text/html")
 	c.Status(code)
 	c.Res.Write([]byte(html))
 }
 
-func (c *Context) Fail(code int, html string) {
-	c.SetHeader("Content-Type", "text/html")
-	c.Status(code)
-	c.Res.Write([]byte(html))
+// ClientIP returns the host part of the remote address.
+func (c *Context) ClientIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		return c.Req.RemoteAddr
+	}
+	return host
 }
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"web/engine/binding"
+	"web/engine/validate"
+)
+
+// ContentType returns the request media type without parameters.
+func (c *Context) ContentType() string {
+	mediaType, _, err := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// Bind picks a binding from the method and Content-Type. On failure it
+// aborts with 400 and returns the error.
+func (c *Context) Bind(obj interface{}) error {
+	return c.MustBindWith(obj, binding.Default(c.Method, c.ContentType()))
+}
+
+func (c *Context) BindJSON(obj interface{}) error {
+	return c.MustBindWith(obj, binding.JSON)
+}
+
+func (c *Context) BindXML(obj interface{}) error {
+	return c.MustBindWith(obj, binding.XML)
+}
+
+func (c *Context) BindQuery(obj interface{}) error {
+	return c.MustBindWith(obj, binding.Query)
+}
+
+func (c *Context) BindHeader(obj interface{}) error {
+	return c.MustBindWith(obj, binding.Header)
+}
+
+func (c *Context) BindURI(obj interface{}) error {
+	if err := c.ShouldBindURI(obj); err != nil {
+		c.abortWithBindError(err)
+		return err
+	}
+	return nil
+}
+
+func (c *Context) MustBindWith(obj interface{}, b binding.Binding) error {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		c.abortWithBindError(err)
+		return err
+	}
+	return nil
+}
+
+// abortWithBindError responds 400, listing the failing fields when err
+// comes from validation, or 413 when the body is too large.
+func (c *Context) abortWithBindError(err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, ErrBodyTooLarge) || errors.As(err, &maxBytesErr) {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, H{"error": err.Error()})
+		return
+	}
+	body := H{"error": err.Error()}
+	var fields validate.ValidationErrors
+	if errors.As(err, &fields) {
+		body["fields"] = fields
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, body)
+}
+
+// ShouldBind is like Bind but leaves the response to the caller.
+func (c *Context) ShouldBind(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Default(c.Method, c.ContentType()))
+}
+
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.JSON)
+}
+
+func (c *Context) ShouldBindXML(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.XML)
+}
+
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Query)
+}
+
+func (c *Context) ShouldBindHeader(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.Header)
+}
+
+func (c *Context) ShouldBindURI(obj interface{}) error {
+	params := make(map[string][]string, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = []string{p.Value}
+	}
+	return binding.URI.BindURI(params, obj)
+}
+
+func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) error {
+	if c.ContentType() == binding.MIMEMultipartPOSTForm && (b == binding.Form || b == binding.FormMultipart) {
+		// parse with the engine and route limits before the binding does
+		if err := c.parseMultipartForm(); err != nil {
+			return err
+		}
+	}
+	return b.Bind(c.Req, obj)
+}
@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"time"
+)
+
+// Set stores a value for the lifetime of the request. It is safe to call
+// from goroutines spawned by handlers.
+func (c *Context) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+func (c *Context) Get(key string) (value interface{}, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value, exists = c.Keys[key]
+	return
+}
+
+// MustGet returns the value for key and panics if it does not exist.
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("engine: key \"" + key + "\" does not exist")
+}
+
+func (c *Context) GetString(key string) (s string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		s, _ = value.(string)
+	}
+	return
+}
+
+func (c *Context) GetBool(key string) (b bool) {
+	if value, ok := c.Get(key); ok && value != nil {
+		b, _ = value.(bool)
+	}
+	return
+}
+
+func (c *Context) GetInt(key string) (i int) {
+	if value, ok := c.Get(key); ok && value != nil {
+		i, _ = value.(int)
+	}
+	return
+}
+
+func (c *Context) GetInt64(key string) (i int64) {
+	if value, ok := c.Get(key); ok && value != nil {
+		i, _ = value.(int64)
+	}
+	return
+}
+
+func (c *Context) GetUint(key string) (u uint) {
+	if value, ok := c.Get(key); ok && value != nil {
+		u, _ = value.(uint)
+	}
+	return
+}
+
+func (c *Context) GetFloat64(key string) (f float64) {
+	if value, ok := c.Get(key); ok && value != nil {
+		f, _ = value.(float64)
+	}
+	return
+}
+
+func (c *Context) GetTime(key string) (t time.Time) {
+	if value, ok := c.Get(key); ok && value != nil {
+		t, _ = value.(time.Time)
+	}
+	return
+}
+
+func (c *Context) GetDuration(key string) (d time.Duration) {
+	if value, ok := c.Get(key); ok && value != nil {
+		d, _ = value.(time.Duration)
+	}
+	return
+}
+
+func (c *Context) GetStringSlice(key string) (ss []string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		ss, _ = value.([]string)
+	}
+	return
+}
+
+func (c *Context) GetStringMap(key string) (sm map[string]interface{}) {
+	if value, ok := c.Get(key); ok && value != nil {
+		sm, _ = value.(map[string]interface{})
+	}
+	return
+}
+
+func (c *Context) GetStringMapString(key string) (sms map[string]string) {
+	if value, ok := c.Get(key); ok && value != nil {
+		sms, _ = value.(map[string]string)
+	}
+	return
+}
+
+// The methods below make *Context a context.Context, so it can be passed
+// straight to downstream calls. Deadline and cancellation come from the
+// request; Value looks in Keys before falling back to the request context.
+
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.Req.Context().Deadline()
+}
+
+// Remaining returns the time left before the request deadline, ok is false
+// when the request has none.
+func (c *Context) Remaining() (remaining time.Duration, ok bool) {
+	deadline, ok := c.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+func (c *Context) Done() <-chan struct{} {
+	return c.Req.Context().Done()
+}
+
+func (c *Context) Err() error {
+	return c.Req.Context().Err()
+}
+
+func (c *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, exists := c.Get(name); exists {
+			return value
+		}
+	}
+	return c.Req.Context().Value(key)
+}
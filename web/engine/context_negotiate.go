@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"web/engine/binding"
+)
+
+// Negotiate lists the formats a handler can respond with, most preferred
+// first, and the data for each. Data is used for formats without their own.
+type Negotiate struct {
+	Offered      []string
+	HTMLName     string
+	HTMLData     interface{}
+	JSONData     interface{}
+	XMLData      interface{}
+	YAMLData     interface{}
+	ProtoBufData interface{}
+	MsgPackData  interface{}
+	Data         interface{}
+}
+
+// Negotiate renders in the offered format the client accepts best, or
+// aborts with 406 when it accepts none of them.
+func (c *Context) Negotiate(code int, config Negotiate) {
+	switch c.NegotiateFormat(config.Offered...) {
+	case binding.MIMEJSON:
+		c.JSON(code, orData(config.JSONData, config.Data))
+	case binding.MIMEXML, binding.MIMEXML2:
+		c.XML(code, orData(config.XMLData, config.Data))
+	case binding.MIMEYAML:
+		c.YAML(code, orData(config.YAMLData, config.Data))
+	case binding.MIMEPROTOBUF:
+		c.ProtoBuf(code, orData(config.ProtoBufData, config.Data))
+	case binding.MIMEMSGPACK:
+		c.MsgPack(code, orData(config.MsgPackData, config.Data))
+	case binding.MIMEHTML:
+		c.HTML(code, config.HTMLName, orData(config.HTMLData, config.Data))
+	case binding.MIMEPlain:
+		c.String(code, "%v", config.Data)
+	default:
+		c.AbortWithStatus(http.StatusNotAcceptable)
+	}
+}
+
+// NegotiateFormat returns the offered media type with the highest quality
+// in the Accept header, taking the most specific matching range for each.
+// Ties go to the earlier offer. It returns "" when nothing is acceptable.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	accepted := parseAccept(c.Req.Header.Get("Accept"))
+	if len(accepted) == 0 {
+		return offered[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offered {
+		q, specificity := 0.0, -1
+		for _, a := range accepted {
+			if s := a.matches(offer); s > specificity {
+				q, specificity = a.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// matches returns how specifically the range matches offer: 2 for an
+// exact match, 1 for type/*, 0 for */*, and -1 when it does not match.
+func (a acceptRange) matches(offer string) int {
+	switch {
+	case a.mediaType == offer:
+		return 2
+	case a.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(a.mediaType, "/*"):
+		if strings.HasPrefix(offer, strings.TrimSuffix(a.mediaType, "*")) {
+			return 1
+		}
+	}
+	return -1
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+		if mediaType == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				// RFC 9110 section 12.4.2 limits weights to [0, 1]
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = min(max(parsed, 0), 1)
+				}
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+func orData(specific interface{}, data interface{}) interface{} {
+	if specific != nil {
+		return specific
+	}
+	return data
+}
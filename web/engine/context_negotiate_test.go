@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"web/engine/binding"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEHTML}
+	cases := []struct {
+		accept string
+		expect string
+	}{
+		{"", binding.MIMEJSON},
+		{"application/xml", binding.MIMEXML},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", binding.MIMEHTML},
+		{"application/json;q=0.5, application/xml", binding.MIMEXML},
+		{"text/*, application/json;q=0.1", binding.MIMEHTML},
+		{"*/*;q=0.5, application/json;q=0", binding.MIMEXML},
+		{"image/png", ""},
+		{"application/xml, text/html;q=5", binding.MIMEXML},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tc.accept)
+		c := newContext(httptest.NewRecorder(), req)
+		if got := c.NegotiateFormat(offered...); got != tc.expect {
+			t.Fatalf("Accept %q should choose %q, got %q", tc.accept, tc.expect, got)
+		}
+	}
+}
+
+type xmlUser struct {
+	Name string `xml:"name"`
+}
+
+func TestNegotiate(t *testing.T) {
+	e := New()
+	e.Get("/user", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{
+			Offered: []string{binding.MIMEJSON, binding.MIMEXML, binding.MIMEPlain, binding.MIMEMSGPACK},
+			XMLData: xmlUser{"geek"},
+			Data:    H{"name": "geek"},
+		})
+	})
+
+	for accept, body := range map[string]string{
+		"application/json": "{\"name\":\"geek\"}\n",
+		"application/xml":  "<xmlUser><name>geek</name></xmlUser>",
+		"text/plain":       "map[name:geek]",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/user", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		if w.Body.String() != body {
+			t.Fatalf("Accept %s should render %q, got %q", accept, body, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Accept", binding.MIMEMSGPACK)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != binding.MIMEMSGPACK {
+		t.Fatalf("msgpack should be negotiable, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Accept", "image/png")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("unacceptable format should respond 406, got %d", w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"net/http"
+
+	"web/engine/render"
+)
+
+// Render sets the status and writes r. If r fails before writing anything,
+// the response becomes a 500 with the error.
+func (c *Context) Render(code int, r render.Render) {
+	c.Status(code)
+
+	if !bodyAllowedForStatus(code) {
+		r.WriteContentType(c.Res)
+		c.Res.WriteHeaderNow()
+		return
+	}
+
+	if err := r.Render(c.Res); err != nil && !c.Res.Written() {
+		c.renderError(err)
+	}
+}
+
+// renderError answers 500 with err when rendering failed before writing.
+func (c *Context) renderError(err error) {
+	c.StatusCode = http.StatusInternalServerError
+	http.Error(c.Res, err.Error(), http.StatusInternalServerError)
+}
+
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.Render(code, render.String{Format: format, Data: values})
+}
+
+func (c *Context) JSON(code int, obj interface{}) {
+	c.Render(code, render.JSON{Data: obj})
+}
+
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, render.IndentedJSON{Data: obj})
+}
+
+// SecureJSON prefixes the body with Engine.SecureJSONPrefix.
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.Render(code, render.SecureJSON{Prefix: c.engine.SecureJSONPrefix, Data: obj})
+}
+
+// JSONP wraps the body in the function named by the callback query
+// parameter, or renders plain JSON without one.
+func (c *Context) JSONP(code int, obj interface{}) {
+	c.Render(code, render.JSONP{Callback: c.Query("callback"), Data: obj})
+}
+
+func (c *Context) AsciiJSON(code int, obj interface{}) {
+	c.Render(code, render.AsciiJSON{Data: obj})
+}
+
+func (c *Context) XML(code int, obj interface{}) {
+	c.Render(code, render.XML{Data: obj})
+}
+
+func (c *Context) YAML(code int, obj interface{}) {
+	c.Render(code, render.YAML{Data: obj})
+}
+
+// ProtoBuf renders obj, which must be a proto.Message.
+func (c *Context) ProtoBuf(code int, obj interface{}) {
+	c.Render(code, render.ProtoBuf{Data: obj})
+}
+
+func (c *Context) MsgPack(code int, obj interface{}) {
+	c.Render(code, render.MsgPack{Data: obj})
+}
+
+func (c *Context) Data(code int, data []byte) {
+	c.Render(code, render.Data{Data: data})
+}
+
+// HTML renders the template called name, as loaded by Engine.LoadHTMLGlob
+// or Engine.LoadHTMLFiles. The page is rendered before anything is written,
+// so a template error results in a 500 instead of a truncated page.
+func (c *Context) HTML(code int, name string, data interface{}) {
+	tmpl, err := c.engine.html.lookup(name, c.engine.HTMLAutoReload)
+	if err != nil {
+		c.renderError(err)
+		return
+	}
+	c.Render(code, render.HTML{Template: tmpl, Name: name, Data: data})
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package engine
+
+// SessionKey is the Context key the sessions middleware stores the Session
+// under.
+const SessionKey = "engine.session"
+
+// Session holds values across requests, see package web/engine/sessions.
+// Changes are only kept once Save is called, before the response body is
+// written.
+type Session interface {
+	ID() string
+	Get(key string) interface{}
+	Set(key string, value interface{})
+	Delete(key string)
+	Clear()
+	// Flash adds a value read once by Flashes, e.g. a message shown after
+	// a redirect.
+	Flash(value interface{})
+	Flashes() []interface{}
+	// RegenerateID gives the session a new ID and discards the old one on
+	// Save. Call it when privileges change, e.g. on login, to prevent
+	// session fixation.
+	RegenerateID()
+	Save() error
+}
+
+// Session returns the session of the request, or nil when the sessions
+// middleware is not used.
+func (c *Context) Session() Session {
+	value, _ := c.Get(SessionKey)
+	session, _ := value.(Session)
+	return session
+}
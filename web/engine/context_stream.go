@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"io"
+	"time"
+
+	"web/engine/render"
+)
+
+// Stream calls step and flushes until step returns false or the client
+// disconnects. It reports whether the client disconnected.
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c.Res)
+			c.Res.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
+// SSEvent writes a server-sent event called name and flushes it.
+func (c *Context) SSEvent(name string, data interface{}) {
+	c.Render(-1, render.SSEvent{Event: name, Data: data})
+	c.Res.Flush()
+}
+
+// SSEHeartbeat writes a comment line, which clients ignore, to keep
+// proxies from closing an idle stream.
+func (c *Context) SSEHeartbeat() {
+	render.SSEvent{}.WriteContentType(c.Res)
+	io.WriteString(c.Res, ": heartbeat\n\n")
+	c.Res.Flush()
+}
+
+// LastEventID returns the id of the last event a reconnecting client saw,
+// from the Last-Event-ID header or the lastEventId query parameter.
+func (c *Context) LastEventID() string {
+	if id := c.Req.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("lastEventId")
+}
+
+// SSEStream writes the events received from events until it is closed or
+// the client disconnects, sending a heartbeat comment every interval when
+// heartbeat is positive. It reports whether the client disconnected.
+func (c *Context) SSEStream(events <-chan render.SSEvent, heartbeat time.Duration) bool {
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// send the headers right away so the client knows the stream is open
+	render.SSEvent{}.WriteContentType(c.Res)
+	c.Res.Flush()
+
+	done := c.Req.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.Render(-1, event)
+			c.Res.Flush()
+		case <-tick:
+			c.SSEHeartbeat()
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"web/engine/render"
+)
+
+func TestStream(t *testing.T) {
+	e := New()
+	e.Get("/count", func(c *Context) {
+		i := 0
+		c.Stream(func(w io.Writer) bool {
+			i++
+			fmt.Fprintf(w, "%d;", i)
+			return i < 3
+		})
+	})
+	e.Get("/progress", func(c *Context) {
+		c.SSEvent("progress", H{"percent": 100})
+	})
+
+	w := get(e, "/count")
+	if w.Body.String() != "1;2;3;" || !w.Flushed {
+		t.Fatalf("expect flushed 1;2;3;, got %q", w.Body.String())
+	}
+
+	w = get(e, "/progress")
+	if w.Header().Get("Content-Type") != "text/event-stream" || w.Body.String() != "event: progress\ndata: {\"percent\":100}\n\n" {
+		t.Fatalf("unexpected event stream %q", w.Body.String())
+	}
+}
+
+func TestSSEStreamDisconnect(t *testing.T) {
+	e := New()
+	disconnected := make(chan bool, 1)
+	e.Get("/events", func(c *Context) {
+		events := make(chan render.SSEvent, 1)
+		id := c.LastEventID()
+		events <- render.SSEvent{ID: id + "1", Data: "resumed"}
+		disconnected <- c.SSEStream(events, 10*time.Millisecond)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "4")
+	w := httptest.NewRecorder()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	e.ServeHTTP(w, req)
+
+	if !<-disconnected {
+		t.Fatalf("SSEStream should report the disconnect")
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "id: 41\ndata: resumed\n\n") || !strings.Contains(body, ": heartbeat\n\n") {
+		t.Fatalf("unexpected stream %q", body)
+	}
+}
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAbort(t *testing.T) {
+	e := New()
+	var after, handled bool
+	e.Use(func(c *Context) {
+		c.Next()
+		after = true
+	})
+	e.Use(func(c *Context) {
+		if c.Query("token") == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	})
+	e.Get("/secret", func(c *Context) {
+		handled = true
+		c.String(http.StatusOK, "secret")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secret", nil))
+	if handled || w.Code != http.StatusUnauthorized {
+		t.Fatalf("aborted request should not reach handler, got %d", w.Code)
+	}
+	if !after {
+		t.Fatalf("middlewares before the abort should resume")
+	}
+
+	handled = false
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/secret?token=1", nil))
+	if !handled || w.Code != http.StatusOK {
+		t.Fatalf("authorized request should reach handler, got %d", w.Code)
+	}
+}
+
+func namedHandler(c *Context) {
+	c.String(http.StatusOK, c.HandlerName())
+}
+
+func TestHandlerName(t *testing.T) {
+	e := New()
+	e.Get("/name", namedHandler)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/name", nil))
+	if !strings.HasSuffix(w.Body.String(), "engine.namedHandler") {
+		t.Fatalf("expect handler name engine.namedHandler, got %s", w.Body.String())
+	}
+}
+
+func TestKeys(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.Set("user", "geek")
+		c.Set("timeout", 3*time.Second)
+		c.Next()
+	})
+	e.Get("/me", func(c *Context) {
+		if c.GetString("user") != "geek" || c.GetDuration("timeout") != 3*time.Second {
+			t.Fatalf("typed getters should return values set by middleware")
+		}
+		if c.GetInt("user") != 0 {
+			t.Fatalf("mismatched type should return zero value")
+		}
+		var ctx context.Context = c
+		if ctx.Value("user") != "geek" {
+			t.Fatalf("context.Context Value should read Keys")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/me", nil))
+}
+
+func TestCopy(t *testing.T) {
+	e := New()
+	e.Use(func(c *Context) {
+		c.Set("user", "geek")
+		cp := c.Copy()
+		cp.Set("user", "copy")
+		if c.GetString("user") != "geek" {
+			t.Fatalf("the copy should have its own keys")
+		}
+		cp.Next()
+		c.Abort()
+	})
+	e.Get("/users/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.GetString("user"), c.Param("id"))
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if w.Body.String() != "copy 1" {
+		t.Fatalf("the copy should continue the chain, got %q", w.Body.String())
+	}
+}
+
+func TestBind(t *testing.T) {
+	e := New()
+	e.Post("/users/:id", func(c *Context) {
+		var uri struct {
+			ID int `uri:"id"`
+		}
+		var body struct {
+			Name string `json:"name"`
+		}
+		if c.BindURI(&uri) != nil || c.Bind(&body) != nil {
+			return
+		}
+		c.String(http.StatusOK, "%d %s", uri.ID, body.Name)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/users/3", strings.NewReader(`{"name":"geek"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "3 geek" {
+		t.Fatalf("expect 3 geek, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid uri should respond 400, got %d", w.Code)
+	}
+}
+
+func TestBindValidation(t *testing.T) {
+	e := New()
+	e.Post("/login", func(c *Context) {
+		var form struct {
+			Username string `form:"username" binding:"required"`
+			Password string `form:"password" binding:"required,min=6"`
+		}
+		if c.Bind(&form) != nil {
+			return
+		}
+		c.String(http.StatusOK, form.Username)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("username=geek&password=123"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"namespace":"Password"`) {
+		t.Fatalf("expect 400 listing Password, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+type csvRender struct {
+	rows [][]string
+}
+
+func (r csvRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	for _, row := range r.rows {
+		if _, err := w.Write([]byte(strings.Join(row, ",") + "\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r csvRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/csv")
+}
+
+func TestRender(t *testing.T) {
+	e := New()
+	e.Get("/csv", func(c *Context) {
+		c.Render(http.StatusOK, csvRender{[][]string{{"a", "b"}, {"1", "2"}}})
+	})
+	e.Get("/secure", func(c *Context) {
+		c.SecureJSON(http.StatusOK, []int{1})
+	})
+	e.Get("/empty", func(c *Context) {
+		c.JSON(http.StatusNoContent, H{"ignored": true})
+	})
+
+	if w := get(e, "/csv"); w.Header().Get("Content-Type") != "text/csv" || w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("custom render should be used, got %s", w.Body.String())
+	}
+	e.SecureJSONPrefix = ")]}',\n"
+	if w := get(e, "/secure"); w.Body.String() != ")]}',\n[1]\n" {
+		t.Fatalf("secure json should use the engine prefix, got %q", w.Body.String())
+	}
+	if w := get(e, "/empty"); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("204 should have no body, got %q", w.Body.String())
+	}
+}
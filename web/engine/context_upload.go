@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// ErrBodyTooLarge is returned when a multipart body exceeds its size limit.
+var ErrBodyTooLarge = errors.New("engine: request body too large")
+
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
+// MultipartLimit overrides the engine limits for the routes it is added to,
+// e.g. e.Post("/avatar", engine.MultipartLimit(1<<20, 4<<20), upload).
+// A zero value keeps the engine setting.
+func MultipartLimit(maxMemory int64, maxSize int64) HandlerFunc {
+	return func(c *Context) {
+		if maxMemory > 0 {
+			c.maxMultipartMemory = maxMemory
+		}
+		if maxSize > 0 {
+			c.maxMultipartSize = maxSize
+		}
+		c.Next()
+	}
+}
+
+// MultipartForm parses a multipart body. Parts beyond the memory limit are
+// streamed to temporary files, which are removed once the request ends.
+// When the body exceeds the size limit the request is aborted with 413 and
+// ErrBodyTooLarge is returned.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	if err := c.parseMultipartForm(); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, H{"error": err.Error()})
+		}
+		return nil, err
+	}
+	return c.Req.MultipartForm, nil
+}
+
+// FormFile returns the first file for the form field name.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File[name]
+	if len(files) == 0 {
+		return nil, http.ErrMissingFile
+	}
+	return files[0], nil
+}
+
+// SaveUploadedFile copies file to dst, creating the parent directories.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
+
+func (c *Context) parseMultipartForm() error {
+	if c.Req.MultipartForm != nil {
+		return nil
+	}
+
+	maxMemory, maxSize := c.maxMultipartMemory, c.maxMultipartSize
+	if maxMemory <= 0 {
+		maxMemory = c.engine.MaxMultipartMemory
+	}
+	if maxSize <= 0 {
+		maxSize = c.engine.MaxMultipartSize
+	}
+	if maxSize > 0 && c.Req.ContentLength > maxSize {
+		return ErrBodyTooLarge
+	}
+	if maxSize > 0 && c.Req.Body != nil {
+		c.Req.Body = http.MaxBytesReader(c.Res, c.Req.Body, maxSize)
+	}
+
+	if err := c.Req.ParseMultipartForm(maxMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return ErrBodyTooLarge
+		}
+		return err
+	}
+	return nil
+}
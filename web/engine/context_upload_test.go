@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func uploadRequest(t *testing.T, path string, field string, content string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile(field, "data.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	e := New()
+	upload := func(c *Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			if !c.IsAborted() {
+				c.String(http.StatusBadRequest, err.Error())
+			}
+			return
+		}
+		if err := c.SaveUploadedFile(file, filepath.Join(dir, "uploads", file.Filename)); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%d", file.Size)
+	}
+	e.Post("/upload", upload)
+	e.Post("/avatar", MultipartLimit(16, 256), upload)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, uploadRequest(t, "/upload", "file", "a,b\n1,2\n"))
+	if w.Code != http.StatusOK || w.Body.String() != "8" {
+		t.Fatalf("expect upload of 8 bytes, got %d %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "uploads", "data.csv")); string(data) != "a,b\n1,2\n" {
+		t.Fatalf("uploaded file should be saved, got %q", data)
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, uploadRequest(t, "/upload", "other", "x"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("missing file should respond 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, uploadRequest(t, "/avatar", "file", strings.Repeat("x", 1024)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload should respond 413, got %d %s", w.Code, w.Body.String())
+	}
+
+	// without a Content-Length the limit is enforced while reading
+	req := uploadRequest(t, "/avatar", "file", strings.Repeat("x", 1024))
+	req.ContentLength = -1
+	req.Body = io.NopCloser(req.Body)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("streamed oversized upload should respond 413, got %d %s", w.Code, w.Body.String())
+	}
+}
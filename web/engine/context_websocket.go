@@ -0,0 +1,20 @@
+package engine
+
+import (
+	"web/engine/websocket"
+)
+
+// Upgrade switches the request to a WebSocket connection, after the group
+// middlewares have run. A nil upgrader uses the defaults. When the handshake
+// fails the error response is already written and the chain is aborted.
+func (c *Context) Upgrade(u *websocket.Upgrader) (*websocket.Conn, error) {
+	if u == nil {
+		u = &websocket.Upgrader{}
+	}
+	conn, err := u.Upgrade(c.Res, c.Req, nil)
+	if err != nil {
+		c.Abort()
+		return nil, err
+	}
+	return conn, nil
+}
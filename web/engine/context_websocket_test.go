@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web/engine/websocket"
+)
+
+func TestUpgrade(t *testing.T) {
+	e := New()
+	ws := e.Group("/ws")
+	ws.Use(func(c *Context) {
+		if c.Query("token") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("user", "geek")
+		c.Next()
+	})
+	ws.Get("/echo", func(c *Context) {
+		conn, err := c.Upgrade(nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.WriteMessage(messageType, append([]byte(c.GetString("user")+": "), data...))
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/echo"
+
+	if _, res, err := websocket.Dial(context.Background(), url, nil); err == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expect the group middleware to reject the upgrade, got %v", err)
+	}
+
+	conn, _, err := websocket.Dial(context.Background(), url+"?token=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	_, data, err := conn.ReadMessage()
+	if err != nil || string(data) != "geek: hello" {
+		t.Fatalf("expect echo through the middleware, got %q %v", data, err)
+	}
+}
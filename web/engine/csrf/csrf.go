@@ -0,0 +1,283 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"web/engine"
+	"web/engine/binding"
+)
+
+const (
+	tokenKey   = "csrf.token"
+	fieldKey   = "csrf.field"
+	failureKey = "csrf.failure"
+	tokenSize  = 32
+)
+
+var (
+	ErrNoToken   = errors.New("csrf: token missing")
+	ErrBadToken  = errors.New("csrf: token invalid")
+	ErrBadOrigin = errors.New("csrf: origin not allowed")
+	ErrNoReferer = errors.New("csrf: referer missing")
+)
+
+type Config struct {
+	// UseSession keeps the token in the session (synchronizer token),
+	// which needs the sessions middleware to run first. By default the
+	// token is kept in a cookie and requests must send it back (double
+	// submit).
+	UseSession bool
+	// CookieName defaults to "_csrf", for the double submit cookie.
+	CookieName string
+	CookiePath string
+	Secure     bool
+	// HeaderName defaults to "X-CSRF-Token" and FieldName, the form field,
+	// to "_csrf".
+	HeaderName string
+	FieldName  string
+	// Scheme is the scheme clients use, "http" or "https". By default it is
+	// "https" for TLS connections. Set it to "https" behind a proxy that
+	// terminates TLS, otherwise the Origin of every browser request fails
+	// to match.
+	Scheme string
+	// TrustedOrigins are other origins allowed to post, such as
+	// "https://admin.example.com". When a proxy rewrites the Host header,
+	// the site's own public origin must be listed here too.
+	TrustedOrigins []string
+	// ExemptRoutes are route patterns that are not checked, such as
+	// "/webhooks/:provider".
+	ExemptRoutes []string
+	// Skip exempts requests it returns true for.
+	Skip func(c *engine.Context) bool
+	// ErrorHandler answers rejected requests, default 403 with a JSON
+	// error. Failure returns the reason.
+	ErrorHandler engine.HandlerFunc
+}
+
+// New checks that requests with unsafe methods carry the token handed out
+// by Token, in the header or the form field. Over HTTPS, it also checks
+// that they come from the same origin, using Origin or else Referer.
+func New(conf Config) engine.HandlerFunc {
+	if conf.CookieName == "" {
+		conf.CookieName = "_csrf"
+	}
+	if conf.CookiePath == "" {
+		conf.CookiePath = "/"
+	}
+	if conf.HeaderName == "" {
+		conf.HeaderName = "X-CSRF-Token"
+	}
+	if conf.FieldName == "" {
+		conf.FieldName = "_csrf"
+	}
+	handler := conf.ErrorHandler
+	if handler == nil {
+		handler = func(c *engine.Context) {
+			c.AbortWithStatusJSON(http.StatusForbidden, engine.H{"error": Failure(c).Error()})
+		}
+	}
+	exempt := make(map[string]bool, len(conf.ExemptRoutes))
+	for _, route := range conf.ExemptRoutes {
+		exempt[route] = true
+	}
+	trusted := make(map[string]bool, len(conf.TrustedOrigins))
+	for _, origin := range conf.TrustedOrigins {
+		trusted[strings.ToLower(origin)] = true
+	}
+
+	return func(c *engine.Context) {
+		token, err := loadToken(c, &conf)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, engine.H{"error": err.Error()})
+			return
+		}
+		c.Set(tokenKey, token)
+		c.Set(fieldKey, conf.FieldName)
+		c.Res.Header().Add("Vary", "Cookie")
+
+		switch c.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			c.Next()
+			return
+		}
+		if exempt[c.FullPath()] || conf.Skip != nil && conf.Skip(c) {
+			c.Next()
+			return
+		}
+
+		if err := checkOrigin(c, scheme(c, &conf), trusted); err != nil {
+			reject(c, handler, err)
+			return
+		}
+
+		sent := c.Req.Header.Get(conf.HeaderName)
+		if sent == "" {
+			if c.ContentType() == binding.MIMEMultipartPOSTForm {
+				// parse with the engine and route limits, oversized bodies
+				// are answered with 413
+				if _, err := c.MultipartForm(); errors.Is(err, engine.ErrBodyTooLarge) {
+					return
+				}
+			}
+			sent = c.Req.PostFormValue(conf.FieldName)
+		}
+		if sent == "" {
+			reject(c, handler, ErrNoToken)
+			return
+		}
+		if subtle.ConstantTimeCompare(unmask(sent), token) != 1 {
+			reject(c, handler, ErrBadToken)
+			return
+		}
+		c.Next()
+	}
+}
+
+func reject(c *engine.Context, handler engine.HandlerFunc, err error) {
+	c.Set(failureKey, err)
+	handler(c)
+	c.Abort()
+}
+
+// loadToken returns the token of the client, creating one on the first
+// request.
+func loadToken(c *engine.Context, conf *Config) ([]byte, error) {
+	if conf.UseSession {
+		session := c.Session()
+		if session == nil {
+			panic("csrf: the sessions middleware must run before csrf")
+		}
+		if encoded, ok := session.Get(tokenKey).(string); ok {
+			if token, err := base64.RawURLEncoding.DecodeString(encoded); err == nil && len(token) == tokenSize {
+				return token, nil
+			}
+		}
+		token := newToken()
+		session.Set(tokenKey, base64.RawURLEncoding.EncodeToString(token))
+		return token, session.Save()
+	}
+
+	if cookie, err := c.Req.Cookie(conf.CookieName); err == nil {
+		if token, err := base64.RawURLEncoding.DecodeString(cookie.Value); err == nil && len(token) == tokenSize {
+			return token, nil
+		}
+	}
+	token := newToken()
+	http.SetCookie(c.Res, &http.Cookie{
+		Name:     conf.CookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(token),
+		Path:     conf.CookiePath,
+		Secure:   conf.Secure || scheme(c, conf) == "https",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return token, nil
+}
+
+func scheme(c *engine.Context, conf *Config) string {
+	if conf.Scheme != "" {
+		return strings.ToLower(conf.Scheme)
+	}
+	if c.Req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// checkOrigin rejects requests from other origins. Over HTTPS a request
+// without Origin must have a same origin Referer (RFC 6454 and the OWASP
+// recommendations), as HTTPS clients are expected to send one.
+func checkOrigin(c *engine.Context, scheme string, trusted map[string]bool) error {
+	self := scheme + "://" + strings.ToLower(c.Req.Host)
+	allowed := func(origin string) bool {
+		origin = strings.ToLower(origin)
+		return origin == self || trusted[origin]
+	}
+
+	if origin := c.Req.Header.Get("Origin"); origin != "" && origin != "null" {
+		if !allowed(origin) {
+			return ErrBadOrigin
+		}
+		return nil
+	}
+	if scheme != "https" {
+		return nil
+	}
+
+	referer := c.Req.Header.Get("Referer")
+	if referer == "" {
+		return ErrNoReferer
+	}
+	u, err := url.Parse(referer)
+	if err != nil || !allowed(u.Scheme+"://"+u.Host) {
+		return ErrBadOrigin
+	}
+	return nil
+}
+
+// Token returns the token to send back, masked with a new random pad on
+// every call so that it does not repeat in compressed responses (BREACH).
+func Token(c *engine.Context) string {
+	value, ok := c.Get(tokenKey)
+	if !ok {
+		panic("csrf: the csrf middleware is not used on this route")
+	}
+	token := value.([]byte)
+	pad := newToken()
+	masked := make([]byte, 2*tokenSize)
+	copy(masked, pad)
+	for i := range token {
+		masked[tokenSize+i] = pad[i] ^ token[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// TemplateField returns a hidden input with the token, to put in forms:
+//
+//	c.HTML(http.StatusOK, "login.tmpl", engine.H{"csrfField": csrf.TemplateField(c)})
+func TemplateField(c *engine.Context) template.HTML {
+	name := c.GetString(fieldKey)
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(name) +
+		`" value="` + Token(c) + `">`)
+}
+
+// Failure returns why the request was rejected, for ErrorHandler.
+func Failure(c *engine.Context) error {
+	value, _ := c.Get(failureKey)
+	err, _ := value.(error)
+	return err
+}
+
+// unmask accepts masked tokens from Token, and raw ones.
+func unmask(sent string) []byte {
+	data, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil {
+		return nil
+	}
+	switch len(data) {
+	case tokenSize:
+		return data
+	case 2 * tokenSize:
+		token := make([]byte, tokenSize)
+		for i := range token {
+			token[i] = data[i] ^ data[tokenSize+i]
+		}
+		return token
+	}
+	return nil
+}
+
+func newToken() []byte {
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return token
+}
@@ -0,0 +1,219 @@
+package csrf
+
+import (
+	"bytes"
+	"crypto/tls"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"web/engine"
+	"web/engine/sessions"
+)
+
+var fieldValue = regexp.MustCompile(`name="(\w+)" value="([\w-]+)"`)
+
+type client struct {
+	e       *engine.Engine
+	cookies []*http.Cookie
+}
+
+func (cl *client) do(req *http.Request) *httptest.ResponseRecorder {
+	for _, cookie := range cl.cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	cl.e.ServeHTTP(w, req)
+	if cookies := w.Result().Cookies(); len(cookies) > 0 {
+		cl.cookies = cookies
+	}
+	return w
+}
+
+// form fetches the login form and returns the field name and token.
+func (cl *client) form(t *testing.T) (string, string) {
+	w := cl.do(httptest.NewRequest(http.MethodGet, "/form", nil))
+	match := fieldValue.FindStringSubmatch(w.Body.String())
+	if match == nil {
+		t.Fatalf("expect a hidden field, got %q", w.Body.String())
+	}
+	return match[1], match[2]
+}
+
+func post(path string, form url.Values, header map[string]string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func newEngine(conf Config, middlewares ...engine.HandlerFunc) *engine.Engine {
+	e := engine.New()
+	e.Use(middlewares...)
+	e.Use(New(conf))
+	e.Get("/form", func(c *engine.Context) {
+		c.String(http.StatusOK, "%s", TemplateField(c))
+	})
+	e.Post("/login", func(c *engine.Context) { c.String(http.StatusOK, "ok") })
+	e.Post("/webhooks/:provider", func(c *engine.Context) { c.String(http.StatusOK, "hook") })
+	return e
+}
+
+func TestDoubleSubmit(t *testing.T) {
+	cl := &client{e: newEngine(Config{FieldName: "token", ExemptRoutes: []string{"/webhooks/:provider"}})}
+	field, token := cl.form(t)
+	if field != "token" {
+		t.Fatalf("expect the configured field name, got %s", field)
+	}
+	if _, second := cl.form(t); second == token {
+		t.Fatalf("tokens should be masked differently on each request")
+	}
+
+	if w := cl.do(post("/login", url.Values{"token": {token}}, nil)); w.Code != http.StatusOK {
+		t.Fatalf("expect the form token to pass, got %d %s", w.Code, w.Body.String())
+	}
+	if w := cl.do(post("/login", nil, map[string]string{"X-CSRF-Token": token})); w.Code != http.StatusOK {
+		t.Fatalf("expect the header token to pass, got %d %s", w.Code, w.Body.String())
+	}
+
+	for name, tc := range map[string]struct {
+		form  url.Values
+		error string
+	}{
+		"missing": {nil, ErrNoToken.Error()},
+		"wrong":   {url.Values{"token": {strings.Repeat("A", 43)}}, ErrBadToken.Error()},
+	} {
+		w := cl.do(post("/login", tc.form, nil))
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), tc.error) {
+			t.Fatalf("%s token: expect 403, got %d %s", name, w.Code, w.Body.String())
+		}
+	}
+
+	// another client has its own cookie, a stolen token does not match it
+	other := &client{e: cl.e}
+	other.form(t)
+	if w := other.do(post("/login", url.Values{"token": {token}}, nil)); w.Code != http.StatusForbidden {
+		t.Fatalf("expect the token of another client to fail, got %d", w.Code)
+	}
+
+	if w := other.do(post("/webhooks/github", nil, nil)); w.Code != http.StatusOK {
+		t.Fatalf("exempt routes should not be checked, got %d", w.Code)
+	}
+}
+
+func TestSynchronizerToken(t *testing.T) {
+	store := sessions.NewMemoryStore()
+	cl := &client{e: newEngine(Config{UseSession: true}, sessions.New(sessions.Config{Store: store}))}
+	_, token := cl.form(t)
+	if len(cl.cookies) != 1 || cl.cookies[0].Name != "session" {
+		t.Fatalf("expect only the session cookie, got %v", cl.cookies)
+	}
+	if w := cl.do(post("/login", url.Values{"_csrf": {token}}, nil)); w.Code != http.StatusOK {
+		t.Fatalf("expect the session token to pass, got %d %s", w.Code, w.Body.String())
+	}
+	cl.cookies = nil
+	if w := cl.do(post("/login", url.Values{"_csrf": {token}}, nil)); w.Code != http.StatusForbidden {
+		t.Fatalf("expect a token without its session to fail, got %d", w.Code)
+	}
+}
+
+func TestOrigin(t *testing.T) {
+	cl := &client{e: newEngine(Config{TrustedOrigins: []string{"https://admin.example.com"}})}
+	_, token := cl.form(t)
+
+	https := func(header map[string]string) *http.Request {
+		req := post("/login", url.Values{"_csrf": {token}}, header)
+		req.Host = "example.com"
+		req.TLS = &tls.ConnectionState{}
+		return req
+	}
+
+	for _, tc := range []struct {
+		header map[string]string
+		code   int
+	}{
+		{map[string]string{"Origin": "https://example.com"}, http.StatusOK},
+		{map[string]string{"Origin": "https://admin.example.com"}, http.StatusOK},
+		{map[string]string{"Origin": "http://example.com"}, http.StatusForbidden},
+		{map[string]string{"Origin": "https://evil.com"}, http.StatusForbidden},
+		{map[string]string{"Referer": "https://example.com/login"}, http.StatusOK},
+		{map[string]string{"Referer": "https://evil.com/login"}, http.StatusForbidden},
+		{nil, http.StatusForbidden},
+	} {
+		if w := cl.do(https(tc.header)); w.Code != tc.code {
+			t.Fatalf("%v: expect %d, got %d %s", tc.header, tc.code, w.Code, w.Body.String())
+		}
+	}
+
+	// plain HTTP only checks Origin when it is sent
+	if w := cl.do(post("/login", url.Values{"_csrf": {token}}, nil)); w.Code != http.StatusOK {
+		t.Fatalf("expect plain HTTP without Referer to pass, got %d", w.Code)
+	}
+}
+
+func TestOriginBehindProxy(t *testing.T) {
+	cl := &client{e: newEngine(Config{Scheme: "https"})}
+	_, token := cl.form(t)
+	if !cl.cookies[0].Secure {
+		t.Fatalf("the cookie should be secure when the scheme is https")
+	}
+
+	// TLS is terminated by the proxy, the request reaches the engine as HTTP
+	proxied := func(header map[string]string) *http.Request {
+		req := post("/login", url.Values{"_csrf": {token}}, header)
+		req.Host = "example.com"
+		return req
+	}
+
+	for _, tc := range []struct {
+		header map[string]string
+		code   int
+	}{
+		{map[string]string{"Origin": "https://example.com"}, http.StatusOK},
+		{map[string]string{"Origin": "http://example.com"}, http.StatusForbidden},
+		{map[string]string{"Origin": "https://evil.com"}, http.StatusForbidden},
+		{map[string]string{"Referer": "https://example.com/login"}, http.StatusOK},
+		{nil, http.StatusForbidden},
+	} {
+		if w := cl.do(proxied(tc.header)); w.Code != tc.code {
+			t.Fatalf("%v: expect %d, got %d %s", tc.header, tc.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMultipartLimit(t *testing.T) {
+	cl := &client{e: newEngine(Config{})}
+	cl.e.MaxMultipartSize = 1024
+	cl.e.Post("/upload", func(c *engine.Context) {
+		if _, err := c.FormFile("file"); err != nil {
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	field, token := cl.form(t)
+
+	upload := func(size int) *http.Request {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		mw.WriteField(field, token)
+		fw, _ := mw.CreateFormFile("file", "a.bin")
+		fw.Write(bytes.Repeat([]byte("a"), size))
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/upload", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+
+	if w := cl.do(upload(10)); w.Code != http.StatusOK {
+		t.Fatalf("expect the multipart token to pass, got %d %s", w.Code, w.Body.String())
+	}
+	if w := cl.do(upload(100 << 10)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect the size limit to apply before the token check, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -2,45 +2,90 @@ package engine
 
 import (
 	"net/http"
+	"sync"
+
+	"web/engine/render"
 )
 
 type Engine struct {
 	router *Router
 	*RouterGroup
 	groups []*RouterGroup
+	html   htmlTemplates
+
+	// HTMLAutoReload parses the html templates again on every render, so
+	// edits show up without a restart. Meant for development only.
+	HTMLAutoReload bool
+
+	// SecureJSONPrefix is written before the body by Context.SecureJSON.
+	SecureJSONPrefix string
+
+	// RedirectTrailingSlash redirects /foo/ to /foo (and the reverse) when
+	// only the other form is registered.
+	RedirectTrailingSlash bool
+	// HandleMethodNotAllowed answers 405 with an Allow header when the path
+	// is registered under other methods only.
+	HandleMethodNotAllowed bool
+	// HandleOPTIONS answers OPTIONS requests that have no route of their own.
+	HandleOPTIONS bool
+
+	// MaxMultipartMemory is the number of bytes of a multipart body kept in
+	// memory, the rest is streamed to temporary files. Default 32 MB.
+	MaxMultipartMemory int64
+	// MaxMultipartSize limits the whole multipart body, 0 means no limit.
+	MaxMultipartSize int64
+
+	// Debug prints the route table when a server starts.
+	Debug bool
+
+	// Server configures the http.Server created by the Run methods.
+	Server ServerConfig
+
+	mu         sync.Mutex
+	servers    []*http.Server
+	closed     bool
+	onStart    []func()
+	onShutdown []func()
+	// closed once Shutdown is over, with its error in shutdownErr
+	shutdownDone chan struct{}
+	shutdownErr  error
 }
 
 func New() *Engine {
 	router := newRouter()
-	engine := &Engine{router: router}
+	engine := &Engine{
+		router:                 router,
+		RedirectTrailingSlash:  true,
+		HandleMethodNotAllowed: true,
+		HandleOPTIONS:          true,
+		MaxMultipartMemory:     defaultMultipartMemory,
+		SecureJSONPrefix:       render.DefaultSecurePrefix,
+		shutdownDone:           make(chan struct{}),
+	}
 	router.setEngine(engine)
 
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
+	router.rebuildFallbacks()
 
 	return engine
 }
 
-func (engine *Engine) Get(path string, handler HandlerFunc) {
-	engine.router.Get(path, handler)
-}
-
-func (engine *Engine) Post(path string, handler HandlerFunc) {
-	engine.router.Post(path, handler)
-}
-
-func (engine *Engine) Put(path string, handler HandlerFunc) {
-	engine.router.Put(path, handler)
-}
-
-func (engine *Engine) Delete(path string, handler HandlerFunc) {
-	engine.router.Delete(path, handler)
-}
-
 func (engine *Engine) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	engine.router.ServeHTTP(res, req)
 }
 
-func (engine *Engine) Run(address string) {
-	http.ListenAndServe(address, engine)
+// NoRoute sets the handlers for requests that match no route. They run
+// after the global middlewares; the default responds 404.
+func (engine *Engine) NoRoute(handlers ...HandlerFunc) {
+	engine.router.noRoute = handlers
+	engine.router.rebuildFallbacks()
+}
+
+// NoMethod sets the handlers for requests whose path is only registered
+// under other methods, when HandleMethodNotAllowed is on. The Allow header
+// is already set when they run; the default responds 405.
+func (engine *Engine) NoMethod(handlers ...HandlerFunc) {
+	engine.router.noMethod = handlers
+	engine.router.rebuildFallbacks()
 }
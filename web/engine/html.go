@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"errors"
+	"html/template"
+	"path/filepath"
+	"sync"
+)
+
+// htmlTemplates holds the parsed templates and enough of their sources to
+// parse them again in auto reload mode.
+type htmlTemplates struct {
+	mu      sync.RWMutex
+	pattern string
+	files   []string
+	layouts string
+	funcMap template.FuncMap
+	left    string
+	right   string
+
+	set   *template.Template            // every page in one set, without layouts
+	pages map[string]*template.Template // one set per page, with layouts
+}
+
+func (t *htmlTemplates) loaded() bool {
+	return t.pattern != "" || len(t.files) > 0
+}
+
+func (t *htmlTemplates) build() (*template.Template, map[string]*template.Template, error) {
+	files := t.files
+	if t.pattern != "" {
+		matches, err := filepath.Glob(t.pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = matches
+	}
+	if len(files) == 0 {
+		return nil, nil, errors.New("engine: no html template files")
+	}
+
+	root := template.New("").Delims(t.left, t.right).Funcs(t.funcMap)
+	if t.layouts == "" {
+		set, err := root.ParseFiles(files...)
+		return set, nil, err
+	}
+
+	shared, err := root.ParseGlob(t.layouts)
+	if err != nil {
+		return nil, nil, err
+	}
+	pages := make(map[string]*template.Template, len(files))
+	for _, file := range files {
+		clone, err := shared.Clone()
+		if err != nil {
+			return nil, nil, err
+		}
+		if pages[filepath.Base(file)], err = clone.ParseFiles(file); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, pages, nil
+}
+
+func (t *htmlTemplates) reload() error {
+	set, pages, err := t.build()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.set, t.pages = set, pages
+	return nil
+}
+
+func (t *htmlTemplates) mustReload() {
+	if t.loaded() {
+		if err := t.reload(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// lookup returns the set that renders the template called name.
+func (t *htmlTemplates) lookup(name string, autoReload bool) (*template.Template, error) {
+	if autoReload {
+		if err := t.reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.pages != nil {
+		if page, ok := t.pages[name]; ok {
+			return page, nil
+		}
+	} else if t.set != nil && t.set.Lookup(name) != nil {
+		return t.set, nil
+	}
+	return nil, errors.New("engine: html template " + name + " is undefined")
+}
+
+// LoadHTMLGlob parses the templates matching pattern. Each one is named by
+// its base file name and may use the others as partials.
+func (engine *Engine) LoadHTMLGlob(pattern string) {
+	engine.html.pattern, engine.html.files = pattern, nil
+	engine.html.mustReload()
+}
+
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	engine.html.pattern, engine.html.files = "", files
+	engine.html.mustReload()
+}
+
+// LoadHTMLLayouts parses the layouts and partials matching pattern into
+// every page. Each page then gets its own set, so pages can define the same
+// blocks, e.g. {{define "content"}}...{{end}}{{template "layout" .}}.
+func (engine *Engine) LoadHTMLLayouts(pattern string) {
+	engine.html.layouts = pattern
+	engine.html.mustReload()
+}
+
+func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
+	engine.html.funcMap = funcMap
+	engine.html.mustReload()
+}
+
+func (engine *Engine) Delims(left, right string) {
+	engine.html.left, engine.html.right = left, right
+	engine.html.mustReload()
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(e *Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHTMLLayouts(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.tmpl"), `[[define "content"]]a [[template "nav" .]][[end]][[template "layout" .]]`)
+	writeFile(t, filepath.Join(dir, "b.tmpl"), `[[define "content"]]b [[.]][[end]][[template "layout" .]]`)
+	writeFile(t, filepath.Join(dir, "layouts/base.tmpl"), `[[define "layout"]]<main>[[template "content" .]]</main>[[end]]`)
+	writeFile(t, filepath.Join(dir, "layouts/nav.tmpl"), `[[define "nav"]]<nav>[[upper .]]</nav>[[end]]`)
+
+	e := New()
+	e.Delims("[[", "]]")
+	e.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	e.LoadHTMLLayouts(filepath.Join(dir, "layouts/*.tmpl"))
+	e.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	e.Get("/:name", func(c *Context) {
+		c.HTML(http.StatusOK, c.Param("name")+".tmpl", "geek")
+	})
+
+	if w := get(e, "/a"); w.Body.String() != "<main>a <nav>GEEK</nav></main>" {
+		t.Fatalf("unexpected page a: %s", w.Body.String())
+	}
+	if w := get(e, "/b"); w.Body.String() != "<main>b geek</main>" {
+		t.Fatalf("unexpected page b: %s", w.Body.String())
+	}
+	if w := get(e, "/c"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("undefined template should respond 500, got %d", w.Code)
+	}
+}
+
+func TestHTMLAutoReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.tmpl")
+	writeFile(t, page, `v1`)
+
+	e := New()
+	e.LoadHTMLFiles(page)
+	e.Get("/", func(c *Context) {
+		c.HTML(http.StatusOK, "index.tmpl", nil)
+	})
+
+	writeFile(t, page, `v2`)
+	if w := get(e, "/"); w.Body.String() != "v1" {
+		t.Fatalf("templates should be cached, got %s", w.Body.String())
+	}
+	e.HTMLAutoReload = true
+	if w := get(e, "/"); w.Body.String() != "v2" {
+		t.Fatalf("templates should be reloaded, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,295 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"web/engine"
+)
+
+// DefaultExcludedContentTypes are media types that are already compressed.
+// Entries ending with "/" match a whole family.
+var DefaultExcludedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed",
+}
+
+type CompressConfig struct {
+	// Level is a compress/flate level, default gzip.DefaultCompression.
+	Level int
+	// MinLength is the body size below which responses are sent as is,
+	// default 1024 bytes. Smaller bodies are buffered until the handler
+	// returns or flushes.
+	MinLength int
+	// ExcludedContentTypes defaults to DefaultExcludedContentTypes.
+	ExcludedContentTypes []string
+	// MaxDecompressedSize caps gzip request bodies once decompressed,
+	// default 32 MB. Reading past it fails with *http.MaxBytesError.
+	MaxDecompressedSize int64
+}
+
+func Compress() engine.HandlerFunc {
+	return CompressWithConfig(CompressConfig{})
+}
+
+// CompressWithConfig compresses responses with gzip or deflate, as the
+// client prefers in Accept-Encoding. Request bodies sent with
+// Content-Encoding: gzip are decompressed before the handlers read them.
+func CompressWithConfig(conf CompressConfig) engine.HandlerFunc {
+	level := conf.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	minLength := conf.MinLength
+	if minLength <= 0 {
+		minLength = 1024
+	}
+	excluded := conf.ExcludedContentTypes
+	if excluded == nil {
+		excluded = DefaultExcludedContentTypes
+	}
+	maxDecompressed := conf.MaxDecompressedSize
+	if maxDecompressed <= 0 {
+		maxDecompressed = 32 << 20
+	}
+	// fail early on an invalid level rather than on the first request
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+		"deflate": {New: func() interface{} {
+			w, _ := zlib.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+	}
+
+	return func(c *engine.Context) {
+		if strings.EqualFold(c.Req.Header.Get("Content-Encoding"), "gzip") && c.Req.Body != nil && c.Req.Body != http.NoBody {
+			// request readers are not pooled, handlers behind Timeout may
+			// still read the body after this middleware returned
+			zr, err := gzip.NewReader(c.Req.Body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, engine.H{"error": "invalid gzip request body"})
+				return
+			}
+			c.Req.Body = gzipBody{http.MaxBytesReader(c.Res, zr, maxDecompressed), c.Req.Body}
+			c.Req.Header.Del("Content-Encoding")
+			c.Req.Header.Del("Content-Length")
+			c.Req.ContentLength = -1
+		}
+
+		res := c.Res
+		w := &compressWriter{
+			ResponseWriter: res,
+			encoding:       negotiateEncoding(c.Req.Header.Values("Accept-Encoding")),
+			minLength:      minLength,
+			excluded:       excluded,
+			pools:          pools,
+		}
+		c.Res = w
+		finished := false
+		defer func() {
+			// on panic the buffered body is dropped, so that recovery can
+			// still answer with an error
+			if !finished {
+				c.Res = res
+			}
+		}()
+
+		c.Next()
+		w.finish()
+		c.Res = res
+		finished = true
+	}
+}
+
+type gzipBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b gzipBody) Close() error {
+	return b.body.Close()
+}
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter buffers up to minLength bytes before it decides whether to
+// compress, then streams the rest.
+type compressWriter struct {
+	engine.ResponseWriter
+	encoding  string
+	minLength int
+	excluded  []string
+	pools     map[string]*sync.Pool
+
+	buf     []byte
+	decided bool
+	zw      compressor
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minLength {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.zw != nil {
+		return w.zw.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.zw != nil {
+		w.zw.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide sets the headers for the buffered body and writes it.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if w.compressible() {
+		if !headerHasToken(header, "Vary", "Accept-Encoding") {
+			header.Add("Vary", "Accept-Encoding")
+		}
+		if w.encoding != "" && len(w.buf) >= w.minLength {
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			// the compressed bytes differ from the identity representation
+			if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+				header.Set("ETag", "W/"+etag)
+			}
+			w.zw = w.pools[w.encoding].Get().(compressor)
+			w.zw.Reset(w.ResponseWriter)
+		}
+	}
+
+	data := w.buf
+	w.buf = nil
+	if len(data) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(data)
+	} else {
+		_, err = w.ResponseWriter.Write(data)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	if status := w.Status(); status < http.StatusOK || status == http.StatusNoContent ||
+		status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = strings.TrimSpace(contentType[:i])
+	}
+	for _, t := range w.excluded {
+		if contentType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *compressWriter) finish() {
+	if !w.decided {
+		// nothing is written after a hijack
+		if w.ResponseWriter.Written() {
+			return
+		}
+		w.decide()
+	}
+	if w.zw != nil {
+		w.zw.Close()
+		w.pools[w.encoding].Put(w.zw)
+		w.zw = nil
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from the Accept-Encoding values,
+// preferring gzip on equal quality. It returns "" for identity.
+func negotiateEncoding(values []string) string {
+	q := map[string]float64{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(part, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			weight := 1.0
+			if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					weight = f
+				}
+			}
+			q[name] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		weight, ok := q[encoding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web/engine"
+)
+
+func TestCompress(t *testing.T) {
+	e := engine.New()
+	e.Use(CompressWithConfig(CompressConfig{MinLength: 100}))
+	large := strings.Repeat("hello gee ", 100)
+	e.Get("/large", func(c *engine.Context) { c.String(http.StatusOK, large) })
+	e.Get("/small", func(c *engine.Context) { c.String(http.StatusOK, "hello") })
+	e.Get("/image", func(c *engine.Context) { c.Data(http.StatusOK, []byte(large)) })
+	e.Get("/png", func(c *engine.Context) {
+		c.SetHeader("Content-Type", "image/png")
+		c.String(http.StatusOK, large)
+	})
+
+	request := func(path string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/large", "br, gzip;q=0.8, deflate;q=0.5")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expect a gzip response, got %v", w.Header())
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != large {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	w = request("/large", "gzip;q=0.5, deflate")
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("expect a deflate response, got %v", w.Header())
+	}
+	zlr, err := zlib.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zlr); string(body) != large {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	for _, tc := range []struct {
+		path, acceptEncoding, vary string
+	}{
+		{"/large", "gzip;q=0, identity", "Accept-Encoding"},
+		{"/small", "gzip", "Accept-Encoding"},
+		{"/png", "gzip", ""},
+	} {
+		w = request(tc.path, tc.acceptEncoding)
+		if w.Header().Get("Content-Encoding") != "" || w.Header().Get("Vary") != tc.vary || w.Body.Len() == 0 {
+			t.Fatalf("%s should not be compressed, got %v", tc.path, w.Header())
+		}
+	}
+
+	// a missing content type is sniffed from the uncompressed body
+	w = request("/image", "*")
+	if w.Header().Get("Content-Encoding") != "gzip" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("expect a sniffed gzip response, got %v", w.Header())
+	}
+}
+
+func TestCompressRequestBody(t *testing.T) {
+	e := engine.New()
+	e.Use(Compress())
+	e.Post("/echo", func(c *engine.Context) {
+		body, err := io.ReadAll(c.Req.Body)
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, "%s", body)
+	})
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"name":"gee"}`))
+	zw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != `{"name":"gee"}` {
+		t.Fatalf("expect the decompressed body, got %d %q", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expect 400 for an invalid body, got %d", w.Code)
+	}
+}
+
+func TestCompressRequestBodyLimit(t *testing.T) {
+	e := engine.New()
+	e.Use(CompressWithConfig(CompressConfig{MaxDecompressedSize: 1024}))
+	e.Post("/users", func(c *engine.Context) {
+		var user struct{ Name string }
+		if c.BindJSON(&user) == nil {
+			c.String(http.StatusOK, "%s", user.Name)
+		}
+	})
+
+	send := func(name string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(`{"Name":"` + name + `"}`))
+		zw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/users", &buf)
+		req.Header.Set("Content-Encoding", "gzip")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("gee"); w.Code != http.StatusOK || w.Body.String() != "gee" {
+		t.Fatalf("expect a small body to bind, got %d %q", w.Code, w.Body.String())
+	}
+	if w := send(strings.Repeat("a", 1<<20)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expect 413 past the decompressed limit, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCompressRecovery(t *testing.T) {
+	e := engine.New()
+	e.Use(RecoveryWithConfig(RecoveryConfig{Output: io.Discard}), Compress())
+	e.Get("/panic", func(c *engine.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "partial") {
+		t.Fatalf("expect the buffered body to be dropped on panic, got %d %q", w.Code, w.Body.String())
+	}
+}
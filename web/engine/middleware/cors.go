@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web/engine"
+)
+
+type CORSConfig struct {
+	// AllowOrigins lists exact origins such as "https://example.com",
+	// wildcard subdomains such as "https://*.example.com", or "*" for any
+	// origin. "*" can not be combined with AllowCredentials.
+	AllowOrigins []string
+	// AllowOriginFunc is consulted when no entry of AllowOrigins matches.
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowMethods []string
+	// AllowHeaders are the request headers accepted in preflights. When
+	// empty the headers asked for by the browser are allowed.
+	AllowHeaders []string
+	// ExposeHeaders are the response headers readable by scripts.
+	ExposeHeaders []string
+	// AllowCredentials lets browsers send cookies and authorization.
+	AllowCredentials bool
+	// MaxAge is how long a preflight may be cached, 0 leaves it to the
+	// browser.
+	MaxAge time.Duration
+}
+
+// CORS allows cross-origin requests from any origin, without credentials.
+func CORS() engine.HandlerFunc {
+	return CORSWithConfig(CORSConfig{AllowOrigins: []string{"*"}})
+}
+
+// CORSWithConfig answers preflight requests itself, so it should be
+// registered with Engine.Use: unmatched OPTIONS requests only run the global
+// middlewares, which lets preflights succeed for routes registered with GET
+// or POST only.
+func CORSWithConfig(conf CORSConfig) engine.HandlerFunc {
+	allowAll := false
+	var exact []string
+	var wildcards [][2]string
+	for _, origin := range conf.AllowOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			allowAll = true
+		} else if prefix, suffix, ok := strings.Cut(origin, "*"); ok {
+			wildcards = append(wildcards, [2]string{prefix, suffix})
+		} else {
+			exact = append(exact, origin)
+		}
+	}
+	if allowAll && conf.AllowCredentials {
+		panic("middleware: CORS can not allow credentials for any origin, list the trusted origins instead")
+	}
+
+	allowed := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		lower := strings.ToLower(origin)
+		for _, o := range exact {
+			if o == lower {
+				return true
+			}
+		}
+		for _, w := range wildcards {
+			if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+				return true
+			}
+		}
+		return conf.AllowOriginFunc != nil && conf.AllowOriginFunc(origin)
+	}
+
+	methods := conf.AllowMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	allowMethods := strings.ToUpper(strings.Join(methods, ", "))
+	allowHeaders := strings.Join(conf.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(conf.ExposeHeaders, ", ")
+	maxAge := ""
+	if conf.MaxAge > 0 {
+		maxAge = strconv.FormatInt(int64(conf.MaxAge/time.Second), 10)
+	}
+
+	return func(c *engine.Context) {
+		origin := c.Req.Header.Get("Origin")
+		header := c.Res.Header()
+		if !allowAll {
+			header.Add("Vary", "Origin")
+		}
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Method == http.MethodOptions && c.Req.Header.Get("Access-Control-Request-Method") != ""
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// same-origin requests carry an Origin too, they are served
+			// without CORS headers and the browser enforces the policy
+			c.Next()
+			return
+		}
+
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := c.Req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"web/engine"
+)
+
+func corsRequest(e *engine.Engine, method string, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflight(t *testing.T) {
+	e := engine.New()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginFunc:  func(origin string) bool { return origin == "http://localhost:8080" },
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	e.Post("/users", func(c *engine.Context) { c.String(http.StatusCreated, "created") })
+
+	for _, origin := range []string{"https://app.example.com", "https://api.example.org", "http://localhost:8080"} {
+		w := corsRequest(e, http.MethodOptions, "/users", map[string]string{
+			"Origin":                        origin,
+			"Access-Control-Request-Method": "POST",
+		})
+		header := w.Header()
+		if w.Code != http.StatusNoContent || header.Get("Access-Control-Allow-Origin") != origin ||
+			header.Get("Access-Control-Allow-Methods") != "GET, POST" ||
+			header.Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" ||
+			header.Get("Access-Control-Allow-Credentials") != "true" ||
+			header.Get("Access-Control-Max-Age") != "3600" {
+			t.Fatalf("unexpected preflight for %s: %d %v", origin, w.Code, header)
+		}
+	}
+
+	for _, origin := range []string{"https://evil.com", "https://example.org", "https://app.example.com.evil.com"} {
+		w := corsRequest(e, http.MethodOptions, "/users", map[string]string{
+			"Origin":                        origin,
+			"Access-Control-Request-Method": "POST",
+		})
+		if w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("preflight from %s should be rejected, got %d", origin, w.Code)
+		}
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	e := engine.New()
+	e.Use(CORSWithConfig(CORSConfig{
+		AllowOrigins:  []string{"https://app.example.com"},
+		ExposeHeaders: []string{"X-Total-Count"},
+	}))
+	e.Get("/users", func(c *engine.Context) { c.String(http.StatusOK, "users") })
+
+	w := corsRequest(e, http.MethodGet, "/users", map[string]string{"Origin": "https://app.example.com"})
+	if w.Body.String() != "users" || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "X-Total-Count" || w.Header().Get("Vary") != "Origin" {
+		t.Fatalf("unexpected response %d %v", w.Code, w.Header())
+	}
+
+	w = corsRequest(e, http.MethodGet, "/users", map[string]string{"Origin": "https://evil.com"})
+	if w.Body.String() != "users" || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("disallowed origins should get no CORS headers, got %v", w.Header())
+	}
+
+	// a plain OPTIONS is not a preflight and keeps the automatic answer
+	w = corsRequest(e, http.MethodOptions, "/users", map[string]string{"Origin": "https://app.example.com"})
+	if w.Code != http.StatusNoContent || !strings.Contains(w.Header().Get("Allow"), "GET") {
+		t.Fatalf("expect automatic OPTIONS, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	e := engine.New()
+	e.Use(CORS())
+	e.Get("/users", func(c *engine.Context) { c.String(http.StatusOK, "users") })
+
+	w := corsRequest(e, http.MethodOptions, "/users", map[string]string{
+		"Origin":                         "https://anywhere.com",
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "X-Requested-With",
+	})
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "*" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "X-Requested-With" {
+		t.Fatalf("unexpected preflight %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSAnyOriginWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("any origin with credentials should panic")
+		}
+	}()
+	CORSWithConfig(CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"web/engine"
+)
+
+// LogParams is what a log line is built from.
+type LogParams struct {
+	Time     time.Time
+	Status   int
+	Latency  time.Duration
+	ClientIP string
+	Method   string
+	Path     string
+	Query    string
+	Size     int
+	Route    string
+}
+
+type LoggerConfig struct {
+	// Output defaults to the writer of the standard logger.
+	Output io.Writer
+	// Format builds a line from the params, it takes precedence over JSON.
+	Format func(LogParams) string
+	// JSON writes one JSON object per request.
+	JSON bool
+	// SkipPaths are request paths that are not logged, e.g. health checks.
+	SkipPaths []string
+}
+
+func Logger() engine.HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{})
+}
+
+func LoggerWithConfig(conf LoggerConfig) engine.HandlerFunc {
+	out := conf.Output
+	if out == nil {
+		out = log.Writer()
+	}
+	format := conf.Format
+	if format == nil {
+		format = defaultLogFormat
+		if conf.JSON {
+			format = jsonLogFormat
+		}
+	}
+	skip := make(map[string]bool, len(conf.SkipPaths))
+	for _, path := range conf.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(c *engine.Context) {
+		if skip[c.Path] {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		io.WriteString(out, format(LogParams{
+			Time:     start,
+			Status:   c.Res.Status(),
+			Latency:  time.Since(start),
+			ClientIP: c.ClientIP(),
+			Method:   c.Method,
+			Path:     c.Path,
+			Query:    c.Req.URL.RawQuery,
+			Size:     c.Res.Size(),
+			Route:    c.FullPath(),
+		}))
+	}
+}
+
+func defaultLogFormat(p LogParams) string {
+	path := p.Path
+	if p.Query != "" {
+		path += "?" + p.Query
+	}
+	return fmt.Sprintf("%s [%d] %s | %s %s | %dB in %v\n",
+		p.Time.Format("2006/01/02 15:04:05"), p.Status, p.ClientIP, p.Method, path, p.Size, p.Latency)
+}
+
+func jsonLogFormat(p LogParams) string {
+	line, _ := json.Marshal(struct {
+		Time      string `json:"time"`
+		Status    int    `json:"status"`
+		LatencyMS int64  `json:"latency_ms"`
+		ClientIP  string `json:"client_ip"`
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Query     string `json:"query,omitempty"`
+		Size      int    `json:"size"`
+		Route     string `json:"route,omitempty"`
+	}{
+		Time:      p.Time.Format(time.RFC3339),
+		Status:    p.Status,
+		LatencyMS: p.Latency.Milliseconds(),
+		ClientIP:  p.ClientIP,
+		Method:    p.Method,
+		Path:      p.Path,
+		Query:     p.Query,
+		Size:      p.Size,
+		Route:     p.Route,
+	})
+	return string(line) + "\n"
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"web/engine"
+)
+
+func serve(e *engine.Engine, method string, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	e := engine.New()
+	e.Use(LoggerWithConfig(LoggerConfig{Output: &buf, SkipPaths: []string{"/health"}}))
+	e.Get("/hello", func(c *engine.Context) { c.String(http.StatusOK, "hello") })
+	e.Get("/health", func(c *engine.Context) { c.String(http.StatusOK, "ok") })
+
+	serve(e, http.MethodGet, "/hello?a=1")
+	if line := buf.String(); !strings.Contains(line, "[200]") || !strings.Contains(line, "GET /hello?a=1 | 5B") {
+		t.Fatalf("unexpected log line %q", line)
+	}
+
+	buf.Reset()
+	serve(e, http.MethodGet, "/health")
+	if buf.Len() != 0 {
+		t.Fatalf("skipped path should not be logged, got %q", buf.String())
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	e := engine.New()
+	e.Use(LoggerWithConfig(LoggerConfig{Output: &buf, JSON: true}))
+	e.Get("/users/:id", func(c *engine.Context) { c.Data(http.StatusCreated, []byte("{}")) })
+
+	serve(e, http.MethodGet, "/users/1")
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["status"] != float64(http.StatusCreated) || entry["route"] != "/users/:id" || entry["size"] != float64(2) {
+		t.Fatalf("unexpected log entry %v", entry)
+	}
+}
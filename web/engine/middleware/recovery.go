@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+
+	"web/engine"
+)
+
+type RecoveryConfig struct {
+	// Output defaults to the writer of the standard logger.
+	Output io.Writer
+	// Handler writes the response after a panic, replacing the default 500.
+	Handler func(c *engine.Context, err interface{})
+	// Stack adds the traceback to the default response, for development.
+	Stack bool
+}
+
+func Recovery() engine.HandlerFunc {
+	return RecoveryWithConfig(RecoveryConfig{})
+}
+
+func RecoveryWithConfig(conf RecoveryConfig) engine.HandlerFunc {
+	out := conf.Output
+	if out == nil {
+		out = log.Writer()
+	}
+
+	return func(c *engine.Context) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			var origin []byte
+			if p, ok := err.(*panicError); ok {
+				err, origin = p.value, p.stack
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			message := fmt.Sprintf("%s", err)
+			if isBrokenPipe(err) {
+				// the client is gone, there is nobody to respond to
+				fmt.Fprintf(out, "%s %s: %s\n", c.Method, c.Path, message)
+				c.Abort()
+				return
+			}
+
+			stack := trace(message)
+			if origin != nil {
+				// raised on another goroutine, whose stack is the useful one
+				stack = message + "\n\n" + string(origin)
+			}
+			fmt.Fprintf(out, "%s\n\n", stack)
+			c.Abort()
+			switch {
+			case c.Res.Written():
+				// too late to change the response
+			case conf.Handler != nil:
+				conf.Handler(c, err)
+			case conf.Stack:
+				c.String(http.StatusInternalServerError, "%s", stack)
+			default:
+				c.Fail(http.StatusInternalServerError, "Internal Server Error")
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+func trace(message string) string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:]) // skip first 3 caller
+
+	var str strings.Builder
+	str.WriteString(message + "\nTraceback:")
+	for _, pc := range pcs[:n] {
+		fn := runtime.FuncForPC(pc)
+		file, line := fn.FileLine(pc)
+		str.WriteString(fmt.Sprintf("\n\t%s:%d", file, line))
+	}
+	return str.String()
+}
+
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(e, &opErr) {
+		return false
+	}
+	var sysErr *os.SyscallError
+	if errors.As(opErr, &sysErr) {
+		message := strings.ToLower(sysErr.Error())
+		return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"web/engine"
+)
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	e := engine.New()
+	e.Use(RecoveryWithConfig(RecoveryConfig{Output: &buf}))
+	e.Get("/panic", func(c *engine.Context) {
+		names := []string{"hello"}
+		c.String(http.StatusOK, names[100])
+	})
+
+	w := serve(e, http.MethodGet, "/panic")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error" {
+		t.Fatalf("expect 500, got %d %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(buf.String(), "index out of range") || !strings.Contains(buf.String(), "Traceback:") {
+		t.Fatalf("panic should be logged with a traceback, got %q", buf.String())
+	}
+}
+
+func TestRecoveryHandler(t *testing.T) {
+	var buf bytes.Buffer
+	e := engine.New()
+	e.Use(RecoveryWithConfig(RecoveryConfig{
+		Output: &buf,
+		Handler: func(c *engine.Context, err interface{}) {
+			c.JSON(http.StatusInternalServerError, engine.H{"error": err})
+		},
+	}))
+	e.Get("/panic", func(c *engine.Context) { panic("boom") })
+	e.Get("/pipe", func(c *engine.Context) {
+		panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+
+	if w := serve(e, http.MethodGet, "/panic"); w.Body.String() != "{\"error\":\"boom\"}\n" {
+		t.Fatalf("custom handler should respond, got %s", w.Body.String())
+	}
+
+	buf.Reset()
+	if w := serve(e, http.MethodGet, "/pipe"); w.Body.Len() != 0 {
+		t.Fatalf("broken pipe should not respond, got %s", w.Body.String())
+	}
+	if strings.Contains(buf.String(), "Traceback:") {
+		t.Fatalf("broken pipe should be logged without a traceback")
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"web/engine"
+)
+
+type TimeoutConfig struct {
+	Timeout time.Duration
+	// Handler answers requests that timed out, default a 503. Use it to
+	// send a 504 or a JSON body instead.
+	Handler engine.HandlerFunc
+}
+
+func Timeout(timeout time.Duration) engine.HandlerFunc {
+	return TimeoutWithConfig(TimeoutConfig{Timeout: timeout})
+}
+
+// TimeoutWithConfig bounds the time spent in the rest of the chain. Handlers
+// see the deadline through the Context, e.g. with c.Remaining, and should
+// pass c to downstream calls so they are cancelled with the request.
+//
+// The remaining handlers run on a copy of the Context in another goroutine,
+// writing into a buffer that is sent once they return. After the timeout,
+// their writes fail with http.ErrHandlerTimeout. Buffering means this is
+// not suited to streaming or WebSocket routes.
+func TimeoutWithConfig(conf TimeoutConfig) engine.HandlerFunc {
+	if conf.Timeout <= 0 {
+		panic("middleware: timeout must be positive")
+	}
+	handler := conf.Handler
+	if handler == nil {
+		handler = func(c *engine.Context) {
+			c.String(http.StatusServiceUnavailable, "503 service unavailable")
+		}
+	}
+
+	return func(c *engine.Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), conf.Timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{ctx: ctx, res: c.Res, header: make(http.Header), status: http.StatusOK}
+		cp := c.Copy()
+		cp.Res = tw
+		cp.Req = c.Req.WithContext(ctx)
+
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					// keep the stack of this goroutine, it is lost once the
+					// panic is raised again in the request goroutine
+					if p != http.ErrAbortHandler {
+						p = &panicError{value: p, stack: debug.Stack()}
+					}
+					panicked <- p
+				}
+			}()
+			// net/http only removes the temporary files of forms parsed on
+			// the original request, and the handlers may outlive it
+			removeForm := func() {
+				if form := cp.Req.MultipartForm; form != nil && form != c.Req.MultipartForm {
+					form.RemoveAll()
+				}
+			}
+			defer removeForm()
+			cp.Next()
+			removeForm()
+			close(done)
+		}()
+
+		// the rest of the chain runs on the copy
+		c.Abort()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		// handlers finishing right at the deadline could only write part of
+		// their response, so it is dropped as well
+		if err := ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				handler(c)
+			}
+			return
+		}
+
+		dst := c.Res.Header()
+		for k, vs := range tw.header {
+			dst[k] = vs
+		}
+		c.Status(tw.status)
+		if tw.buf.Len() > 0 {
+			c.Res.Write(tw.buf.Bytes())
+		}
+		for k, v := range cp.Keys {
+			c.Set(k, v)
+		}
+	}
+}
+
+// timeoutWriter buffers the response of the handlers running on the copy.
+type timeoutWriter struct {
+	ctx    context.Context
+	res    engine.ResponseWriter
+	header http.Header
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	status  int
+	written bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.buf.Write(data)
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush does nothing, the response is sent when the handlers return.
+func (w *timeoutWriter) Flush() {}
+
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("middleware: hijacking is not supported with a timeout")
+}
+
+func (w *timeoutWriter) Push(target string, opts *http.PushOptions) error {
+	return http.ErrNotSupported
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.res
+}
+
+// panicError carries a panic of the handlers run by Timeout to the request
+// goroutine, along with the stack it was raised from. Recovery unwraps it.
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (p *panicError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+func (p *panicError) Unwrap() error {
+	err, _ := p.value.(error)
+	return err
+}
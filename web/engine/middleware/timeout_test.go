@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"web/engine"
+)
+
+func TestTimeout(t *testing.T) {
+	e := engine.New()
+	var user string
+	e.Use(func(c *engine.Context) {
+		c.Next()
+		user = c.GetString("user")
+	})
+	e.Use(Timeout(50 * time.Millisecond))
+	e.Get("/fast", func(c *engine.Context) {
+		remaining, ok := c.Remaining()
+		if !ok || remaining <= 0 || remaining > 50*time.Millisecond {
+			t.Errorf("unexpected remaining time %v %v", remaining, ok)
+		}
+		c.Set("user", "geek")
+		c.SetHeader("X-Handler", "fast")
+		c.String(http.StatusCreated, "fast")
+	})
+	late := make(chan error, 1)
+	e.Get("/slow", func(c *engine.Context) {
+		<-c.Done()
+		_, err := c.Res.Write([]byte("late"))
+		late <- err
+	})
+
+	w := serve(e, http.MethodGet, "/fast")
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Handler") != "fast" {
+		t.Fatalf("expect the buffered response, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+	if user != "geek" {
+		t.Fatalf("keys set by the handler should be visible to outer middlewares, got %q", user)
+	}
+
+	w = serve(e, http.MethodGet, "/slow")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "503 service unavailable" {
+		t.Fatalf("expect 503, got %d %q", w.Code, w.Body.String())
+	}
+	if err := <-late; err != http.ErrHandlerTimeout {
+		t.Fatalf("late writes should fail, got %v", err)
+	}
+}
+
+func TestTimeoutMultipart(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	e := engine.New()
+	e.MaxMultipartMemory = 1
+	e.Use(Timeout(time.Second))
+	e.Post("/upload", func(c *engine.Context) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, file.Filename)
+	})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "a.txt")
+	part.Write([]byte(strings.Repeat("x", 1024)))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expect the upload to succeed, got %d %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("temporary upload files should be removed, found %d", len(entries))
+	}
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	e := engine.New()
+	e.Use(RecoveryWithConfig(RecoveryConfig{Output: io.Discard}))
+	e.Use(TimeoutWithConfig(TimeoutConfig{
+		Timeout: 10 * time.Millisecond,
+		Handler: func(c *engine.Context) {
+			c.JSON(http.StatusGatewayTimeout, engine.H{"error": "upstream timeout"})
+		},
+	}))
+	e.Get("/slow", func(c *engine.Context) { <-c.Done() })
+	e.Get("/panic", func(c *engine.Context) { panic("boom") })
+
+	w := serve(e, http.MethodGet, "/slow")
+	if w.Code != http.StatusGatewayTimeout || w.Body.String() != "{\"error\":\"upstream timeout\"}\n" {
+		t.Fatalf("expect the custom 504, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = serve(e, http.MethodGet, "/panic")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("panics should reach recovery, got %d", w.Code)
+	}
+}
+
+func TestTimeoutPanicValue(t *testing.T) {
+	boom := errors.New("boom")
+	var recovered interface{}
+	var out bytes.Buffer
+	e := engine.New()
+	e.Use(RecoveryWithConfig(RecoveryConfig{
+		Output: &out,
+		Handler: func(c *engine.Context, err interface{}) {
+			recovered = err
+			c.String(http.StatusInternalServerError, "recovered")
+		},
+	}))
+	e.Use(Timeout(time.Second))
+	e.Get("/panic", func(c *engine.Context) { panic(boom) })
+
+	if w := serve(e, http.MethodGet, "/panic"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panics should reach recovery, got %d", w.Code)
+	}
+	if recovered != boom {
+		t.Fatalf("recovery should get the original value, got %#v", recovered)
+	}
+	if !strings.Contains(out.String(), "TestTimeoutPanicValue.func") {
+		t.Fatalf("the log should hold the stack of the handler, got %s", out.String())
+	}
+}
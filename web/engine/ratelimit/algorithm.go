@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Result describes the quota of a key after a request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the quota is fully available again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, set when
+	// the request was denied.
+	RetryAfter time.Duration
+}
+
+// Algorithm decides whether a request is allowed. Denied requests do not
+// count against the quota.
+type Algorithm interface {
+	Take(state *State, now time.Time) Result
+	// TTL is how long an idle key has to be kept.
+	TTL() time.Duration
+}
+
+// TokenBucket allows bursts of up to Burst requests, refilled at Rate
+// requests per second.
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+func (b TokenBucket) Take(state *State, now time.Time) Result {
+	burst := float64(b.Burst)
+	tokens := burst
+	if !state.Time.IsZero() {
+		tokens = math.Min(burst, state.Count+now.Sub(state.Time).Seconds()*b.Rate)
+	}
+
+	res := Result{Limit: b.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = b.duration(1 - tokens)
+	}
+	state.Count, state.Time = tokens, now
+
+	res.Remaining = int(tokens)
+	res.Reset = b.duration(burst - tokens)
+	return res
+}
+
+func (b TokenBucket) TTL() time.Duration {
+	return b.duration(float64(b.Burst))
+}
+
+func (b TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.Rate * float64(time.Second))
+}
+
+// SlidingWindow allows Limit requests in any Window. It counts requests in
+// fixed windows and weights the previous window by how much of it is still
+// within the sliding one.
+type SlidingWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (w SlidingWindow) Take(state *State, now time.Time) Result {
+	start := now.Truncate(w.Window)
+	if !state.Time.Equal(start) {
+		if state.Time.Equal(start.Add(-w.Window)) {
+			state.Previous = state.Count
+		} else {
+			state.Previous = 0
+		}
+		state.Count, state.Time = 0, start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(w.Window)
+	limit := float64(w.Limit)
+	used := state.Previous*weight + state.Count
+
+	res := Result{Limit: w.Limit}
+	if used+1 <= limit {
+		state.Count++
+		used++
+		res.Allowed = true
+	} else if state.Count+1 > limit {
+		res.RetryAfter = w.Window - elapsed
+	} else {
+		// wait until enough of the previous window has slid out
+		slide := 1 - (limit-1-state.Count)/state.Previous
+		res.RetryAfter = time.Duration(slide*float64(w.Window)) - elapsed
+	}
+	res.Remaining = int(math.Max(0, limit-used))
+	// requests stop counting once their window slid out entirely
+	switch {
+	case state.Count > 0:
+		res.Reset = 2*w.Window - elapsed
+	case state.Previous > 0:
+		res.Reset = w.Window - elapsed
+	}
+	return res
+}
+
+func (w SlidingWindow) TTL() time.Duration {
+	return 2 * w.Window
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"web/engine"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// KeyFunc returns the key requests are counted under. An empty key is not
+// limited.
+type KeyFunc func(c *engine.Context) string
+
+type Config struct {
+	// Algorithm is required, e.g. TokenBucket or SlidingWindow.
+	Algorithm Algorithm
+	// Key defaults to ByIP.
+	Key KeyFunc
+	// Store defaults to a new MemoryStore.
+	Store Store
+	// Prefix separates the keys of limiters sharing a Store.
+	Prefix string
+	// Handler answers limited requests, default 429 with a JSON error.
+	Handler engine.HandlerFunc
+	// ErrorHandler is called when the Store fails. By default the request
+	// is let through.
+	ErrorHandler func(c *engine.Context, err error)
+}
+
+// checkAlgorithm panics on parameters the builtin algorithms can not work
+// with, such as a zero Window that would divide by zero on every request.
+func checkAlgorithm(algorithm Algorithm) {
+	switch a := algorithm.(type) {
+	case *TokenBucket:
+		checkAlgorithm(*a)
+	case *SlidingWindow:
+		checkAlgorithm(*a)
+	case TokenBucket:
+		if a.Rate <= 0 || a.Burst <= 0 {
+			panic("ratelimit: TokenBucket needs a positive Rate and Burst")
+		}
+	case SlidingWindow:
+		if a.Limit <= 0 || a.Window <= 0 {
+			panic("ratelimit: SlidingWindow needs a positive Limit and Window")
+		}
+	}
+}
+
+// New returns a middleware counting requests per key. It sets the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and
+// Retry-After on limited requests.
+func New(conf Config) engine.HandlerFunc {
+	if conf.Algorithm == nil {
+		panic("ratelimit: an algorithm is required")
+	}
+	checkAlgorithm(conf.Algorithm)
+	key := conf.Key
+	if key == nil {
+		key = ByIP()
+	}
+	store := conf.Store
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	handler := conf.Handler
+	if handler == nil {
+		handler = func(c *engine.Context) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, engine.H{"error": "rate limit exceeded"})
+		}
+	}
+	ttl := conf.Algorithm.TTL()
+
+	return func(c *engine.Context) {
+		k := key(c)
+		if k == "" {
+			c.Next()
+			return
+		}
+
+		var res Result
+		err := store.Update(c, conf.Prefix+k, ttl, func(state *State) {
+			res = conf.Algorithm.Take(state, now())
+		})
+		if err != nil {
+			if conf.ErrorHandler != nil {
+				conf.ErrorHandler(c, err)
+			}
+			if !c.IsAborted() {
+				c.Next()
+			}
+			return
+		}
+
+		header := c.Res.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		header.Set("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			header.Set("Retry-After", seconds(res.RetryAfter))
+			handler(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds rounds d up, so that clients do not retry too early.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+func ByIP() KeyFunc {
+	return func(c *engine.Context) string {
+		return c.ClientIP()
+	}
+}
+
+// ByHeader keys requests by a header such as an API key, falling back to
+// the client IP without it.
+func ByHeader(name string) KeyFunc {
+	return func(c *engine.Context) string {
+		if value := c.Req.Header.Get(name); value != "" {
+			return value
+		}
+		return c.ClientIP()
+	}
+}
+
+// ByRoute shares one quota between all clients of a route.
+func ByRoute() KeyFunc {
+	return func(c *engine.Context) string {
+		return c.Method + " " + c.FullPath()
+	}
+}
+
+// Compose joins keys, e.g. Compose(ByRoute(), ByIP()) for a quota per
+// client and route. Requests are not limited when a key is empty.
+func Compose(keys ...KeyFunc) KeyFunc {
+	return func(c *engine.Context) string {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			if parts[i] = key(c); parts[i] == "" {
+				return ""
+			}
+		}
+		return strings.Join(parts, "|")
+	}
+}
@@ -0,0 +1,191 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"web/engine"
+)
+
+func setClock(t *testing.T, start time.Time) *time.Time {
+	clock := start
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+	return &clock
+}
+
+func serve(e *engine.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := setClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := engine.New()
+	e.Use(New(Config{Algorithm: TokenBucket{Rate: 1, Burst: 2}}))
+	e.Get("/login", func(c *engine.Context) { c.String(http.StatusOK, "ok") })
+
+	for i, remaining := range []string{"1", "0"} {
+		w := serve(e, "/login", nil)
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Fatalf("request %d should be allowed, got %d %v", i, w.Code, w.Header())
+		}
+	}
+
+	*clock = clock.Add(500 * time.Millisecond)
+	w := serve(e, "/login", nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" || w.Header().Get("RateLimit-Reset") != "2" {
+		t.Fatalf("expect 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+
+	*clock = clock.Add(500 * time.Millisecond)
+	if w := serve(e, "/login", nil); w.Code != http.StatusOK {
+		t.Fatalf("a token should be refilled, got %d", w.Code)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := setClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	algorithm := SlidingWindow{Limit: 4, Window: time.Minute}
+	var state State
+
+	for i := 0; i < 4; i++ {
+		if res := algorithm.Take(&state, *clock); !res.Allowed || res.Remaining != 3-i {
+			t.Fatalf("request %d should be allowed, got %+v", i, res)
+		}
+	}
+	if res := algorithm.Take(&state, *clock); res.Allowed || res.RetryAfter != time.Minute {
+		t.Fatalf("expect the window to be full, got %+v", res)
+	}
+
+	// half of the previous window still counts: 4*0.5 = 2 requests
+	*clock = clock.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		if res := algorithm.Take(&state, *clock); !res.Allowed {
+			t.Fatalf("request %d should be allowed, got %+v", i, res)
+		}
+	}
+	res := algorithm.Take(&state, *clock)
+	if res.Allowed || res.RetryAfter != 15*time.Second || res.Reset != 90*time.Second {
+		t.Fatalf("expect to wait for the previous window to slide, got %+v", res)
+	}
+
+	*clock = clock.Add(3 * time.Minute)
+	if res := algorithm.Take(&state, *clock); !res.Allowed || res.Remaining != 3 {
+		t.Fatalf("old windows should be forgotten, got %+v", res)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	setClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	e := engine.New()
+	limited := e.Group("/api")
+	limited.Use(New(Config{
+		Algorithm: SlidingWindow{Limit: 1, Window: time.Minute},
+		Key:       Compose(ByRoute(), ByHeader("X-API-Key")),
+	}))
+	limited.Get("/search", func(c *engine.Context) { c.String(http.StatusOK, "search") })
+	limited.Get("/users/:id", func(c *engine.Context) { c.String(http.StatusOK, "user") })
+
+	for _, tc := range []struct {
+		path, key string
+		code      int
+	}{
+		{"/api/search", "a", http.StatusOK},
+		{"/api/search", "a", http.StatusTooManyRequests},
+		{"/api/search", "b", http.StatusOK},
+		{"/api/users/1", "a", http.StatusOK},
+		{"/api/users/2", "a", http.StatusTooManyRequests},
+	} {
+		if w := serve(e, tc.path, map[string]string{"X-API-Key": tc.key}); w.Code != tc.code {
+			t.Fatalf("%s with key %s: expect %d, got %d", tc.path, tc.key, tc.code, w.Code)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Update(context.Context, string, time.Duration, func(*State)) error {
+	return errors.New("unavailable")
+}
+
+func TestInvalidAlgorithm(t *testing.T) {
+	for _, algorithm := range []Algorithm{
+		TokenBucket{Rate: 0, Burst: 1},
+		TokenBucket{Rate: 1, Burst: 0},
+		&TokenBucket{Rate: -1, Burst: 1},
+		SlidingWindow{Limit: 0, Window: time.Minute},
+		SlidingWindow{Limit: 1, Window: 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%+v should panic", algorithm)
+				}
+			}()
+			New(Config{Algorithm: algorithm})
+		}()
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	e := engine.New()
+	e.Get("/open", New(Config{Algorithm: TokenBucket{Rate: 1, Burst: 1}, Store: failingStore{}}), func(c *engine.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	e.Get("/closed", New(Config{
+		Algorithm: TokenBucket{Rate: 1, Burst: 1},
+		Store:     failingStore{},
+		ErrorHandler: func(c *engine.Context, err error) {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		},
+	}), func(c *engine.Context) { c.String(http.StatusOK, "ok") })
+
+	if w := serve(e, "/open", nil); w.Code != http.StatusOK {
+		t.Fatalf("store errors should let requests through by default, got %d", w.Code)
+	}
+	if w := serve(e, "/closed", nil); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expect the error handler to answer, got %d", w.Code)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	clock := setClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	store := NewMemoryStore()
+	count := func(key string) (n float64) {
+		store.Update(context.Background(), key, time.Second, func(state *State) {
+			state.Count++
+			n = state.Count
+		})
+		return n
+	}
+
+	count("a")
+	if n := count("a"); n != 2 {
+		t.Fatalf("expect the state to be kept, got %v", n)
+	}
+	*clock = clock.Add(2 * time.Second)
+	if n := count("a"); n != 1 {
+		t.Fatalf("expect an expired key to start over, got %v", n)
+	}
+
+	for _, key := range []string{"b", "c", "d"} {
+		count(key)
+	}
+	*clock = clock.Add(2 * time.Minute)
+	for i := 0; i < shardCount*4; i++ {
+		count(string(rune('e' + i)))
+	}
+	if n := store.Len(); n != shardCount*4 {
+		t.Fatalf("expired keys should be swept, %d keys left", n)
+	}
+}
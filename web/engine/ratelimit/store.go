@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// State is what the algorithms keep per key.
+type State struct {
+	// Count is the number of tokens left for a token bucket, or of requests
+	// in the current window for a sliding window.
+	Count float64
+	// Previous is the number of requests in the previous window.
+	Previous float64
+	// Time is the last refill, or the start of the current window. It is
+	// zero for a new key.
+	Time time.Time
+}
+
+// Store keeps the state of every key. Stores for external backends must
+// apply Update atomically per key, e.g. with optimistic locking.
+type Store interface {
+	// Update runs fn on the state of key and keeps the result for ttl.
+	// Missing and expired keys start from the zero State.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(*State)) error
+}
+
+const (
+	shardCount    = 32
+	sweepInterval = time.Minute
+)
+
+// MemoryStore is a Store for a single process. Keys are spread over shards
+// to reduce lock contention, and expired keys are swept as shards are used.
+type MemoryStore struct {
+	shards [shardCount]shard
+}
+
+type shard struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	state   State
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*entry)
+	}
+	return s
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(*State)) error {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	sh := &s.shards[h.Sum32()%shardCount]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	t := now()
+	if t.Sub(sh.lastSweep) > sweepInterval {
+		for k, e := range sh.entries {
+			if t.After(e.expires) {
+				delete(sh.entries, k)
+			}
+		}
+		sh.lastSweep = t
+	}
+
+	e, ok := sh.entries[key]
+	if !ok || t.After(e.expires) {
+		e = &entry{}
+		sh.entries[key] = e
+	}
+	fn(&e.state)
+	e.expires = t.Add(ttl)
+	return nil
+}
+
+// Len returns the number of keys stored, expired or not.
+func (s *MemoryStore) Len() int {
+	n := 0
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		n += len(sh.entries)
+		sh.mu.Unlock()
+	}
+	return n
+}
@@ -0,0 +1,28 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+)
+
+// HTML executes the template called Name from Template.
+type HTML struct {
+	Template *template.Template
+	Name     string
+	Data     interface{}
+}
+
+func (r HTML) Render(w http.ResponseWriter) error {
+	var buf bytes.Buffer
+	if err := r.Template.ExecuteTemplate(&buf, r.Name, r.Data); err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r HTML) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "text/html; charset=utf-8")
+}
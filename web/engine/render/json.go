@@ -0,0 +1,164 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	jsonContentType      = "application/json; charset=utf-8"
+	jsonpContentType     = "application/javascript; charset=utf-8"
+	jsonASCIIContentType = "application/json"
+)
+
+// DefaultSecurePrefix is written by SecureJSON when Prefix is empty.
+const DefaultSecurePrefix = "while(1);"
+
+type JSON struct {
+	Data interface{}
+}
+
+type IndentedJSON struct {
+	Data interface{}
+}
+
+// SecureJSON prefixes the body so it can not be executed as a script by a
+// cross-site page, clients strip Prefix before parsing.
+type SecureJSON struct {
+	Prefix string
+	Data   interface{}
+}
+
+// JSONP wraps the body in a call to Callback. An invalid callback name is
+// rejected, an empty one renders plain JSON.
+type JSONP struct {
+	Callback string
+	Data     interface{}
+}
+
+// AsciiJSON escapes every non-ASCII character as \uXXXX.
+type AsciiJSON struct {
+	Data interface{}
+}
+
+var callbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+
+func encodeJSON(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r JSON) Render(w http.ResponseWriter) error {
+	body, err := encodeJSON(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(body)
+	return err
+}
+
+func (r JSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+func (r IndentedJSON) Render(w http.ResponseWriter) error {
+	body, err := json.MarshalIndent(r.Data, "", "    ")
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write(body)
+	return err
+}
+
+func (r IndentedJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+func (r SecureJSON) Render(w http.ResponseWriter) error {
+	body, err := encodeJSON(r.Data)
+	if err != nil {
+		return err
+	}
+	prefix := r.Prefix
+	if prefix == "" {
+		prefix = DefaultSecurePrefix
+	}
+	r.WriteContentType(w)
+	if _, err := w.Write([]byte(prefix)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (r SecureJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonContentType)
+}
+
+func (r JSONP) Render(w http.ResponseWriter) error {
+	if r.Callback == "" {
+		return JSON{Data: r.Data}.Render(w)
+	}
+	if !callbackRegexp.MatchString(r.Callback) {
+		return fmt.Errorf("render: invalid jsonp callback %q", r.Callback)
+	}
+
+	body, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	r.WriteContentType(w)
+	_, err = w.Write([]byte(r.Callback + "(" + string(body) + ");"))
+	return err
+}
+
+func (r JSONP) WriteContentType(w http.ResponseWriter) {
+	if r.Callback == "" {
+		writeContentType(w, jsonContentType)
+		return
+	}
+	writeContentType(w, jsonpContentType)
+}
+
+func (r AsciiJSON) Render(w http.ResponseWriter) error {
+	body, err := encodeJSON(r.Data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for len(body) > 0 {
+		char, size := utf8.DecodeRune(body)
+		if char < utf8.RuneSelf {
+			buf.WriteByte(body[0])
+		} else if char > 0xFFFF {
+			r1, r2 := utf16Surrogates(char)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", r1, r2)
+		} else {
+			fmt.Fprintf(&buf, "\\u%04x", char)
+		}
+		body = body[size:]
+	}
+
+	r.WriteContentType(w)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func (r AsciiJSON) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, jsonASCIIContentType)
+}
+
+func utf16Surrogates(char rune) (rune, rune) {
+	char -= 0x10000
+	return 0xD800 + (char>>10)&0x3FF, 0xDC00 + char&0x3FF
+}
@@ -0,0 +1,18 @@
+package render
+
+import "net/http"
+
+// Render writes a response body in one format. Implementations encode the
+// whole body before writing, so an encoding error leaves the response
+// untouched.
+type Render interface {
+	Render(http.ResponseWriter) error
+	WriteContentType(w http.ResponseWriter)
+}
+
+func writeContentType(w http.ResponseWriter, value string) {
+	header := w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", value)
+	}
+}
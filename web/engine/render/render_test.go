@@ -0,0 +1,88 @@
+package render
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func renderBody(t *testing.T, r Render) (string, string) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	if err := r.Render(w); err != nil {
+		t.Fatal(err)
+	}
+	return w.Header().Get("Content-Type"), w.Body.String()
+}
+
+func TestJSONVariants(t *testing.T) {
+	data := map[string]interface{}{"name": "极客", "tags": []int{1}}
+
+	cases := []struct {
+		r           Render
+		contentType string
+		body        string
+	}{
+		{JSON{data}, jsonContentType, "{\"name\":\"极客\",\"tags\":[1]}\n"},
+		{IndentedJSON{map[string]int{"a": 1}}, jsonContentType, "{\n    \"a\": 1\n}"},
+		{SecureJSON{"", []int{1}}, jsonContentType, "while(1);[1]\n"},
+		{JSONP{"cb.done", []int{1}}, jsonpContentType, "cb.done([1]);"},
+		{JSONP{"", []int{1}}, jsonContentType, "[1]\n"},
+		{AsciiJSON{data}, jsonASCIIContentType, "{\"name\":\"\\u6781\\u5ba2\",\"tags\":[1]}\n"},
+	}
+	for _, tc := range cases {
+		contentType, body := renderBody(t, tc.r)
+		if contentType != tc.contentType || body != tc.body {
+			t.Fatalf("%T: expect %s %q, got %s %q", tc.r, tc.contentType, tc.body, contentType, body)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	if err := (JSONP{"alert(1)//", 1}).Render(w); err == nil || w.Body.Len() != 0 {
+		t.Fatalf("invalid callback should fail without writing")
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	type user struct {
+		Name string `xml:"name" yaml:"name" msgpack:"name"`
+	}
+
+	if _, body := renderBody(t, XML{user{"geek"}}); body != "<user><name>geek</name></user>" {
+		t.Fatalf("unexpected xml %q", body)
+	}
+	if _, body := renderBody(t, YAML{user{"geek"}}); body != "name: geek\n" {
+		t.Fatalf("unexpected yaml %q", body)
+	}
+	if _, body := renderBody(t, String{Format: "50%%"}); body != "50%" {
+		t.Fatalf("unexpected string %q", body)
+	}
+
+	_, body := renderBody(t, MsgPack{user{"geek"}})
+	var decoded user
+	if err := msgpack.Unmarshal([]byte(body), &decoded); err != nil || decoded.Name != "geek" {
+		t.Fatalf("unexpected msgpack %q", body)
+	}
+
+	contentType, body := renderBody(t, ProtoBuf{wrapperspb.String("geek")})
+	message := &wrapperspb.StringValue{}
+	if err := proto.Unmarshal([]byte(body), message); err != nil || message.Value != "geek" || contentType != "application/x-protobuf" {
+		t.Fatalf("unexpected protobuf %s %q", contentType, body)
+	}
+	if err := (ProtoBuf{"geek"}).Render(httptest.NewRecorder()); err == nil {
+		t.Fatalf("non proto.Message should fail")
+	}
+}
+
+func TestSSEvent(t *testing.T) {
+	contentType, body := renderBody(t, SSEvent{Event: "progress", ID: "7\n", Retry: 1000, Data: "line1\nline2"})
+	if contentType != "text/event-stream" || body != "id: 7\nevent: progress\nretry: 1000\ndata: line1\ndata: line2\n\n" {
+		t.Fatalf("unexpected event %s %q", contentType, body)
+	}
+	if _, body := renderBody(t, SSEvent{Data: map[string]int{"done": 50}}); body != "data: {\"done\":50}\n\n" {
+		t.Fatalf("unexpected json event %q", body)
+	}
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SSEvent is one server-sent event. Data is written as is when it is a
+// string or []byte and as JSON otherwise; multi-line data is split over
+// several data fields.
+type SSEvent struct {
+	Event string
+	ID    string
+	Retry uint // reconnection delay in milliseconds, 0 leaves it unset
+	Data  interface{}
+}
+
+var fieldReplacer = strings.NewReplacer("\n", "", "\r", "")
+
+func (r SSEvent) Render(w http.ResponseWriter) error {
+	var data string
+	switch d := r.Data.(type) {
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	case nil:
+	default:
+		encoded, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(encoded)
+	}
+
+	var buf bytes.Buffer
+	if r.ID != "" {
+		buf.WriteString("id: " + fieldReplacer.Replace(r.ID) + "\n")
+	}
+	if r.Event != "" {
+		buf.WriteString("event: " + fieldReplacer.Replace(r.Event) + "\n")
+	}
+	if r.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatUint(uint64(r.Retry), 10) + "\n")
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	r.WriteContentType(w)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r SSEvent) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// stop nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+}
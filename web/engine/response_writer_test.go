@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newResponseWriter(rec)
+	if w.Status() != http.StatusOK || w.Written() {
+		t.Fatalf("new writer should default to 200 and not be written")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
+	w.Header().Set("X-Late", "1")
+	if w.Written() || rec.Code != http.StatusOK {
+		t.Fatalf("WriteHeader should be deferred until the first write")
+	}
+
+	w.Write([]byte("hello"))
+	w.WriteHeader(http.StatusInternalServerError)
+	if rec.Code != http.StatusAccepted || w.Status() != http.StatusAccepted || rec.Header().Get("X-Late") != "1" {
+		t.Fatalf("expect the last status before writing, got %d", rec.Code)
+	}
+	if w.Size() != 5 || !w.Written() {
+		t.Fatalf("expect 5 bytes written, got %d", w.Size())
+	}
+
+	w.Flush()
+	if !rec.Flushed {
+		t.Fatalf("Flush should pass through")
+	}
+	if err := w.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Fatalf("Push should report ErrNotSupported, got %v", err)
+	}
+}
+
+func TestContextStatusTracking(t *testing.T) {
+	e := New()
+	var status, size, statusCode int
+	e.Use(func(c *Context) {
+		c.Next()
+		status, size, statusCode = c.Res.Status(), c.Res.Size(), c.StatusCode
+	})
+	e.Get("/data", func(c *Context) { c.Res.Write([]byte("raw")) })
+	e.Get("/empty", func(c *Context) { c.Status(http.StatusNoContent) })
+	e.Get("/bad", func(c *Context) { c.JSON(http.StatusOK, H{"f": func() {}}) })
+	e.Get("/event", func(c *Context) { c.SSEvent("ping", "1") })
+	e.Get("/page", func(c *Context) { c.HTML(http.StatusOK, "missing.tmpl", nil) })
+
+	get(e, "/data")
+	if status != http.StatusOK || size != 3 {
+		t.Fatalf("expect 200 and 3 bytes, got %d %d", status, size)
+	}
+
+	if w := get(e, "/empty"); w.Code != http.StatusNoContent || statusCode != http.StatusNoContent {
+		t.Fatalf("status without body should still be sent, got %d", w.Code)
+	}
+
+	if w := get(e, "/bad"); w.Code != http.StatusInternalServerError || status != http.StatusInternalServerError ||
+		statusCode != http.StatusInternalServerError {
+		t.Fatalf("failed JSON encoding should respond 500, got %d", w.Code)
+	}
+
+	if w := get(e, "/event"); w.Code != http.StatusOK || statusCode != http.StatusOK {
+		t.Fatalf("events should keep the 200, got %d %d", w.Code, statusCode)
+	}
+	if w := get(e, "/page"); w.Code != http.StatusInternalServerError || statusCode != http.StatusInternalServerError {
+		t.Fatalf("missing templates should respond 500, got %d %d", w.Code, statusCode)
+	}
+}
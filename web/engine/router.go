@@ -2,55 +2,194 @@ package engine
 
 import (
 	"net/http"
+	"sort"
 	"strings"
-
-	"github.com/julienschmidt/httprouter"
 )
 
 type HandlerFunc func(*Context)
 
+type route struct {
+	method   string
+	path     string
+	group    *RouterGroup
+	own      []HandlerFunc // handlers passed at registration
+	handlers []HandlerFunc // group middlewares followed by own
+}
+
 type Router struct {
-	*httprouter.Router
+	trees  map[string]*node
+	routes []*route
 	engine *Engine
+
+	// handlers for requests without a route, and the same prefixed with the
+	// global middlewares
+	noRoute     []HandlerFunc
+	noMethod    []HandlerFunc
+	allNoRoute  []HandlerFunc
+	allNoMethod []HandlerFunc
+	allOptions  []HandlerFunc
 }
 
 func newRouter() *Router {
-	r := httprouter.New()
-	return &Router{r, nil}
+	return &Router{trees: make(map[string]*node)}
 }
 
 func (router *Router) setEngine(e *Engine) {
 	router.engine = e
 }
 
-func (router *Router) add(method string, path string, handler HandlerFunc) {
-	router.HandlerFunc(method, path, func(res http.ResponseWriter, req *http.Request) {
-		var middlewares []HandlerFunc
-		for _, group := range router.engine.groups {
-			if strings.HasPrefix(req.URL.Path, group.prefix) {
-				middlewares = append(middlewares, group.middlewares...)
-			}
-		}
+func serveNotFound(c *Context) {
+	c.String(http.StatusNotFound, "404 page not found")
+}
 
-		c := newContext(res, req)
-		c.handlers = append(middlewares, handler)
+func serveMethodNotAllowed(c *Context) {
+	c.String(http.StatusMethodNotAllowed, "405 method not allowed")
+}
 
-		c.Next()
-	})
+func serveOptions(c *Context) {
+	c.Status(http.StatusNoContent)
 }
 
-func (router *Router) Get(path string, handler HandlerFunc) {
-	router.add(http.MethodGet, path, handler)
+func (router *Router) add(method string, path string, group *RouterGroup, handlers []HandlerFunc) {
+	if method == "" {
+		panic("engine: method must not be empty in route " + path)
+	}
+	if len(handlers) == 0 {
+		panic("engine: there must be at least one handler in route " + path)
+	}
+
+	root, ok := router.trees[method]
+	if !ok {
+		root = newNode()
+		router.trees[method] = root
+	}
+
+	r := &route{
+		method: method,
+		path:   path,
+		group:  group,
+		own:    handlers,
+	}
+	r.handlers = group.combineHandlers(handlers)
+	root.insert(path, r)
+	router.routes = append(router.routes, r)
 }
 
-func (router *Router) Post(path string, handler HandlerFunc) {
-	router.add(http.MethodPost, path, handler)
+// rebuild recomputes the chains of routes registered under group, so that
+// middlewares added by a later Use still apply to them.
+func (router *Router) rebuild(group *RouterGroup) {
+	for _, r := range router.routes {
+		if r.group.isWithin(group) {
+			r.handlers = r.group.combineHandlers(r.own)
+		}
+	}
+	if group == router.engine.RouterGroup {
+		router.rebuildFallbacks()
+	}
+}
+
+func (router *Router) rebuildFallbacks() {
+	global := router.engine.RouterGroup
+
+	noRoute, noMethod := router.noRoute, router.noMethod
+	if len(noRoute) == 0 {
+		noRoute = []HandlerFunc{serveNotFound}
+	}
+	if len(noMethod) == 0 {
+		noMethod = []HandlerFunc{serveMethodNotAllowed}
+	}
+	router.allNoRoute = global.combineHandlers(noRoute)
+	router.allNoMethod = global.combineHandlers(noMethod)
+	router.allOptions = global.combineHandlers([]HandlerFunc{serveOptions})
 }
 
-func (router *Router) Put(path string, handler HandlerFunc) {
-	router.add(http.MethodPut, path, handler)
+func (router *Router) lookup(method string, path string) (*route, Params) {
+	root, ok := router.trees[method]
+	if !ok {
+		return nil, nil
+	}
+	var params Params
+	r := root.search(path, &params)
+	return r, params
 }
 
-func (router *Router) Delete(path string, handler HandlerFunc) {
-	router.add(http.MethodDelete, path, handler)
+// allowed returns the value of the Allow header for path, or "" when no
+// other method than skip is registered for it. "*" matches every method.
+func (router *Router) allowed(path string, skip string) string {
+	var methods []string
+	hasOptions := false
+	for method, root := range router.trees {
+		if method == skip {
+			continue
+		}
+		var params Params
+		if path == "*" || root.search(path, &params) != nil {
+			methods = append(methods, method)
+			hasOptions = hasOptions || method == http.MethodOptions
+		}
+	}
+	if len(methods) == 0 {
+		return ""
+	}
+
+	if router.engine.HandleOPTIONS && !hasOptions {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func (router *Router) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	engine := router.engine
+	path := req.URL.Path
+	c := newContext(res, req)
+	c.engine = engine
+
+	if r, params := router.lookup(req.Method, path); r != nil {
+		c.Params = params
+		c.fullPath = r.path
+		c.handlers = r.handlers
+		c.Next()
+		c.Res.WriteHeaderNow()
+		return
+	}
+
+	if engine.RedirectTrailingSlash && req.Method != http.MethodConnect && path != "/" {
+		alternate := path + "/"
+		if strings.HasSuffix(path, "/") {
+			alternate = path[:len(path)-1]
+		}
+		if r, _ := router.lookup(req.Method, alternate); r != nil {
+			code := http.StatusMovedPermanently
+			if req.Method != http.MethodGet {
+				code = http.StatusTemporaryRedirect
+			}
+			target := *req.URL
+			target.Path = alternate
+			http.Redirect(res, req, target.String(), code)
+			return
+		}
+	}
+
+	// Unmatched requests still run the global middlewares, so that logging,
+	// recovery and CORS apply to them.
+	// The status is set up front, so handlers that write nothing still
+	// respond with it.
+	c.handlers = router.allNoRoute
+	c.Status(http.StatusNotFound)
+	if req.Method == http.MethodOptions && engine.HandleOPTIONS {
+		if allow := router.allowed(path, http.MethodOptions); allow != "" {
+			res.Header().Set("Allow", allow)
+			c.handlers = router.allOptions
+			c.Status(http.StatusNoContent)
+		}
+	} else if engine.HandleMethodNotAllowed {
+		if allow := router.allowed(path, req.Method); allow != "" {
+			res.Header().Set("Allow", allow)
+			c.handlers = router.allNoMethod
+			c.Status(http.StatusMethodNotAllowed)
+		}
+	}
+	c.Next()
+	c.Res.WriteHeaderNow()
 }
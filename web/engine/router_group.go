@@ -22,19 +22,83 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 	return newGroup
 }
 
-func (group *RouterGroup) add(method string, path string, handler HandlerFunc) {
+func (group *RouterGroup) add(method string, path string, handlers []HandlerFunc) {
 	pattern := group.prefix + path
-	group.engine.router.add(method, pattern, handler)
+	group.engine.router.add(method, pattern, group, handlers)
 }
 
-func (group *RouterGroup) Get(path string, handler HandlerFunc) {
-	group.add(http.MethodGet, path, handler)
+// combineHandlers returns the middlewares of group and all of its parents,
+// outermost first, followed by the route handlers.
+func (group *RouterGroup) combineHandlers(handlers []HandlerFunc) []HandlerFunc {
+	var chain []*RouterGroup
+	for g := group; g != nil; g = g.parent {
+		chain = append(chain, g)
+	}
+
+	var combined []HandlerFunc
+	for i := len(chain) - 1; i >= 0; i-- {
+		combined = append(combined, chain[i].middlewares...)
+	}
+	return append(combined, handlers...)
 }
 
-func (group *RouterGroup) Post(path string, handler HandlerFunc) {
-	group.add(http.MethodPost, path, handler)
+func (group *RouterGroup) isWithin(ancestor *RouterGroup) bool {
+	for g := group; g != nil; g = g.parent {
+		if g == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// anyMethods are the methods registered by Any.
+var anyMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodHead, http.MethodOptions, http.MethodDelete, http.MethodConnect,
+	http.MethodTrace,
+}
+
+// Handle registers handlers for method and path. The handlers run after the
+// middlewares of the group, and may themselves call Next.
+func (group *RouterGroup) Handle(method string, path string, handlers ...HandlerFunc) {
+	group.add(method, path, handlers)
+}
+
+func (group *RouterGroup) Get(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodGet, path, handlers)
+}
+
+func (group *RouterGroup) Post(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodPost, path, handlers)
+}
+
+func (group *RouterGroup) Put(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodPut, path, handlers)
+}
+
+func (group *RouterGroup) Patch(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodPatch, path, handlers)
+}
+
+func (group *RouterGroup) Delete(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodDelete, path, handlers)
+}
+
+func (group *RouterGroup) Head(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodHead, path, handlers)
+}
+
+func (group *RouterGroup) Options(path string, handlers ...HandlerFunc) {
+	group.add(http.MethodOptions, path, handlers)
+}
+
+func (group *RouterGroup) Any(path string, handlers ...HandlerFunc) {
+	for _, method := range anyMethods {
+		group.add(method, path, handlers)
+	}
 }
 
 func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
 	group.middlewares = append(group.middlewares, middlewares...)
+	group.engine.router.rebuild(group)
 }
@@ -0,0 +1,175 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTreeSearch(t *testing.T) {
+	root := newNode()
+	for _, path := range []string{"/", "/hello", "/hello/:name", "/hello/b/c", "/assets/*filepath", "/v1/", "/v1/hello"} {
+		root.insert(path, &route{path: path})
+	}
+
+	cases := []struct {
+		path   string
+		route  string
+		params Params
+	}{
+		{"/", "/", nil},
+		{"/hello", "/hello", nil},
+		{"/hello/geek", "/hello/:name", Params{{"name", "geek"}}},
+		{"/hello/b/c", "/hello/b/c", nil},
+		{"/hello/b", "/hello/:name", Params{{"name", "b"}}},
+		{"/assets/css/main.css", "/assets/*filepath", Params{{"filepath", "/css/main.css"}}},
+		{"/assets/", "/assets/*filepath", Params{{"filepath", "/"}}},
+		{"/v1/", "/v1/", nil},
+		{"/hello/", "", nil},
+		{"/hello/geek/x", "", nil},
+		{"/v10/hello", "", nil},
+	}
+	for _, tc := range cases {
+		var params Params
+		r := root.search(tc.path, &params)
+		if tc.route == "" {
+			if r != nil {
+				t.Fatalf("%s should not match, got %s", tc.path, r.path)
+			}
+			continue
+		}
+		if r == nil || r.path != tc.route {
+			t.Fatalf("%s should match %s, got %v", tc.path, tc.route, r)
+		}
+		if !reflect.DeepEqual(params, tc.params) {
+			t.Fatalf("%s params expect %v, got %v", tc.path, tc.params, params)
+		}
+	}
+}
+
+func TestTreeConflict(t *testing.T) {
+	root := newNode()
+	root.insert("/users/:id", &route{})
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("conflicting param names should panic")
+		}
+	}()
+	root.insert("/users/:name/posts", &route{})
+}
+
+func TestGroupMiddlewares(t *testing.T) {
+	e := New()
+	var trace []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Context) {
+			trace = append(trace, name)
+			c.Next()
+		}
+	}
+	e.Use(mark("global"))
+	v1 := e.Group("/v1")
+	v1.Get("/hello", func(c *Context) { c.String(http.StatusOK, "v1") })
+	v1.Use(mark("v1"))
+	v10 := e.Group("/v10")
+	v10.Get("/hello", func(c *Context) { c.String(http.StatusOK, "v10") })
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v10/hello", nil))
+	if !reflect.DeepEqual(trace, []string{"global"}) {
+		t.Fatalf("/v10 should only run global middleware, got %v", trace)
+	}
+
+	trace = nil
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/hello", nil))
+	if !reflect.DeepEqual(trace, []string{"global", "v1"}) {
+		t.Fatalf("/v1 should run global and v1 middleware, got %v", trace)
+	}
+}
+
+func TestTrailingSlashRedirect(t *testing.T) {
+	e := New()
+	e.Get("/v1/", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1?a=b", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/v1/?a=b" {
+		t.Fatalf("expect redirect to /v1/?a=b, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestMethods(t *testing.T) {
+	e := New()
+	handler := func(c *Context) { c.String(http.StatusOK, c.Method) }
+	api := e.Group("/api")
+	api.Get("/users", handler)
+	api.Post("/users", handler)
+	api.Patch("/users/:id", handler)
+	api.Put("/users/:id", handler)
+	api.Delete("/users/:id", handler)
+	api.Handle("PURGE", "/cache", handler)
+	e.Any("/any", handler)
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPatch, "/api/users/1"},
+		{http.MethodDelete, "/api/users/1"},
+		{"PURGE", "/api/cache"},
+		{http.MethodTrace, "/any"},
+	} {
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Body.String() != tc.method {
+			t.Fatalf("%s %s should be routed, got %d %s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "DELETE, OPTIONS, PATCH, PUT" {
+		t.Fatalf("expect 405 with Allow, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/users", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, OPTIONS, POST" {
+		t.Fatalf("expect automatic OPTIONS, got %d %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expect 404, got %d", w.Code)
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	e := New()
+	var global int
+	e.Use(func(c *Context) {
+		global++
+		c.Next()
+	})
+	e.Get("/users", func(c *Context) { c.String(http.StatusOK, "users") })
+	e.NoRoute(func(c *Context) {
+		c.JSON(http.StatusNotFound, H{"error": "not found"})
+	})
+	e.NoMethod(func(c *Context) {
+		c.JSON(http.StatusMethodNotAllowed, H{"error": "method not allowed"})
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "{\"error\":\"not found\"}\n" {
+		t.Fatalf("expect json 404, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Fatalf("expect json 405 with Allow, got %d %s", w.Code, w.Body.String())
+	}
+
+	if global != 2 {
+		t.Fatalf("global middleware should run for unmatched requests, ran %d times", global)
+	}
+}
@@ -0,0 +1,54 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+	// Middlewares are the names of the handlers running before Handler,
+	// global ones first.
+	Middlewares []string `json:"middlewares"`
+}
+
+// Routes returns the registered routes, in registration order.
+func (engine *Engine) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(engine.router.routes))
+	for _, r := range engine.router.routes {
+		names := make([]string, 0, len(r.handlers))
+		for _, handler := range r.handlers {
+			names = append(names, nameOfFunction(handler))
+		}
+		routes = append(routes, RouteInfo{
+			Method:      r.method,
+			Path:        r.path,
+			Handler:     names[len(names)-1],
+			Middlewares: names[:len(names)-1],
+		})
+	}
+	return routes
+}
+
+// DebugRoutes responds with the route table as JSON. Register it where
+// only trusted clients reach it:
+//
+//	admin.Get("/debug/routes", engine.DebugRoutes)
+func DebugRoutes(c *Context) {
+	c.IndentedJSON(http.StatusOK, c.engine.Routes())
+}
+
+func (engine *Engine) printRoutes(out io.Writer) {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "[engine] METHOD\tPATH\tHANDLER\tMIDDLEWARES")
+	for _, route := range engine.Routes() {
+		fmt.Fprintf(w, "[engine] %s\t%s\t%s\t%s\n", route.Method, route.Path, route.Handler, strings.Join(route.Middlewares, ", "))
+	}
+	w.Flush()
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func authorize(c *Context) {
+	c.Next()
+}
+
+func listUsers(c *Context) {
+	c.String(http.StatusOK, "users")
+}
+
+func TestRoutes(t *testing.T) {
+	e := New()
+	e.Get("/", listUsers)
+	api := e.Group("/api")
+	api.Use(authorize)
+	api.Post("/users/:id", listUsers)
+	e.Get("/debug/routes", DebugRoutes)
+
+	expect := []RouteInfo{
+		{http.MethodGet, "/", "web/engine.listUsers", []string{}},
+		{http.MethodPost, "/api/users/:id", "web/engine.listUsers", []string{"web/engine.authorize"}},
+		{http.MethodGet, "/debug/routes", "web/engine.DebugRoutes", []string{}},
+	}
+	if routes := e.Routes(); !reflect.DeepEqual(routes, expect) {
+		t.Fatalf("expect %v, got %v", expect, routes)
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	var routes []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil || !reflect.DeepEqual(routes, expect) {
+		t.Fatalf("expect the route table as json, got %s", w.Body.String())
+	}
+
+	var buf bytes.Buffer
+	e.printRoutes(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[2], "POST    /api/users/:id") || !strings.HasSuffix(lines[2], "web/engine.authorize") {
+		t.Fatalf("unexpected route table\n%s", buf.String())
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type ServerConfig struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+// OnStart registers fn to run once a server is listening.
+func (engine *Engine) OnStart(fn func()) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	engine.onStart = append(engine.onStart, fn)
+}
+
+// OnShutdown registers fn to run after Shutdown has drained the servers.
+func (engine *Engine) OnShutdown(fn func()) {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	engine.onShutdown = append(engine.onShutdown, fn)
+}
+
+// Run listens on the TCP address and serves until Shutdown. It then waits
+// for Shutdown to finish draining requests and running the hooks, and
+// returns its error.
+func (engine *Engine) Run(address string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return engine.RunListener(l)
+}
+
+func (engine *Engine) RunTLS(address string, certFile string, keyFile string) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return engine.serve(l, func(srv *http.Server) error {
+		err := srv.ServeTLS(l, certFile, keyFile)
+		if !errors.Is(err, http.ErrServerClosed) {
+			// ServeTLS returns before serving when the key pair can not
+			// be loaded, leaving the listener open
+			l.Close()
+		}
+		return err
+	})
+}
+
+// RunUnix listens on the unix socket file, which is removed on close.
+func (engine *Engine) RunUnix(file string) error {
+	l, err := net.Listen("unix", file)
+	if err != nil {
+		return err
+	}
+	return engine.RunListener(l)
+}
+
+func (engine *Engine) RunListener(l net.Listener) error {
+	return engine.serve(l, func(srv *http.Server) error {
+		return srv.Serve(l)
+	})
+}
+
+func (engine *Engine) serve(l net.Listener, serve func(*http.Server) error) error {
+	srv := &http.Server{
+		Handler:           engine,
+		ReadTimeout:       engine.Server.ReadTimeout,
+		ReadHeaderTimeout: engine.Server.ReadHeaderTimeout,
+		WriteTimeout:      engine.Server.WriteTimeout,
+		IdleTimeout:       engine.Server.IdleTimeout,
+		MaxHeaderBytes:    engine.Server.MaxHeaderBytes,
+	}
+
+	engine.mu.Lock()
+	if engine.closed {
+		engine.mu.Unlock()
+		l.Close()
+		return http.ErrServerClosed
+	}
+	engine.servers = append(engine.servers, srv)
+	hooks := engine.onStart
+	engine.mu.Unlock()
+
+	if engine.Debug {
+		engine.printRoutes(log.Writer())
+	}
+	for _, fn := range hooks {
+		fn()
+	}
+
+	err := serve(srv)
+	engine.mu.Lock()
+	engine.servers = slices.DeleteFunc(engine.servers, func(s *http.Server) bool { return s == srv })
+	engine.mu.Unlock()
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	// Serve returns as soon as Shutdown starts, the requests in flight are
+	// still running
+	<-engine.shutdownDone
+	return engine.shutdownErr
+}
+
+// Shutdown stops accepting connections and waits for active handlers to
+// return or ctx to expire, then runs the OnShutdown hooks. The Run methods
+// return once it is over.
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.mu.Lock()
+	first := !engine.closed
+	engine.closed = true
+	servers := engine.servers
+	engine.servers = nil
+	hooks := engine.onShutdown
+	engine.mu.Unlock()
+
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	err := errors.Join(errs...)
+	if first {
+		for _, fn := range hooks {
+			fn()
+		}
+		engine.shutdownErr = err
+		close(engine.shutdownDone)
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package engine
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	started := make(chan struct{})
+	handling := make(chan struct{})
+	var shutdown int
+	e.OnStart(func() { close(started) })
+	e.OnShutdown(func() { shutdown++ })
+	e.Get("/slow", func(c *Context) {
+		close(handling)
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- e.RunListener(l) }()
+	<-started
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+		body <- string(data)
+	}()
+	<-handling
+
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request should complete, got %s", got)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run should return nil after Shutdown, got %v", err)
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if shutdown != 1 {
+		t.Fatalf("OnShutdown hooks should run once, ran %d times", shutdown)
+	}
+}
+
+func TestRunWaitsForShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := New()
+	started := make(chan struct{})
+	handling := make(chan struct{})
+	finished := make(chan struct{})
+	e.OnStart(func() { close(started) })
+	e.OnShutdown(func() { close(finished) })
+	e.Get("/slow", func(c *Context) {
+		close(handling)
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- e.RunListener(l) }()
+	<-started
+	go http.Get("http://" + l.Addr().String() + "/slow")
+	<-handling
+	go e.Shutdown(context.Background())
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run should return nil after Shutdown, got %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatalf("Run should only return once Shutdown drained the requests")
+	}
+}
+
+func TestRunError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if err := New().Run(l.Addr().String()); err == nil {
+		t.Fatalf("Run on a used port should return an error")
+	}
+}
+
+func TestRunTLSError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close()
+
+	e := New()
+	if err := e.RunTLS(address, "missing.crt", "missing.key"); err == nil {
+		t.Fatalf("RunTLS without a key pair should return an error")
+	}
+	if len(e.servers) != 0 {
+		t.Fatalf("failed servers should be forgotten, got %d", len(e.servers))
+	}
+	l, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("the listener should be closed on error: %v", err)
+	}
+	l.Close()
+}
@@ -0,0 +1,82 @@
+package sessions
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// MemoryStore keeps sessions in the process, the cookie only holds the ID.
+// Values are stored gob encoded, like in a shared backend, so changes after
+// Save are not kept.
+type MemoryStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry)}
+}
+
+func (s *MemoryStore) Load(ctx context.Context, cookie string) (string, map[string]interface{}, error) {
+	s.mu.Lock()
+	entry, ok := s.sessions[cookie]
+	s.mu.Unlock()
+	if !ok || !time.Now().Before(entry.expires) {
+		return "", nil, ErrNotFound
+	}
+
+	var values map[string]interface{}
+	if err := gob.NewDecoder(bytes.NewReader(entry.data)).Decode(&values); err != nil {
+		return "", nil, err
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return cookie, values, nil
+}
+
+func (s *MemoryStore) Save(ctx context.Context, id string, values map[string]interface{}, maxAge time.Duration) (string, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for key, entry := range s.sessions {
+			if !now.Before(entry.expires) {
+				delete(s.sessions, key)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.sessions[id] = memoryEntry{data: buf.Bytes(), expires: now.Add(maxAge)}
+	return id, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// Len returns the number of sessions stored, expired or not.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
@@ -0,0 +1,181 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"web/engine"
+)
+
+func init() {
+	// values are encoded with gob, register other types stored in
+	// sessions the same way
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// ErrNotFound is returned by stores for unknown, expired or tampered
+// sessions. The request then starts a new session.
+var ErrNotFound = errors.New("sessions: session not found")
+
+// Store keeps session values. The cookie only holds what Save returns: the
+// session ID for server-side stores, the encrypted values for CookieStore.
+type Store interface {
+	// Load returns the ID and values of the session in cookie.
+	Load(ctx context.Context, cookie string) (id string, values map[string]interface{}, err error)
+	// Save keeps values for maxAge and returns the new cookie value.
+	Save(ctx context.Context, id string, values map[string]interface{}, maxAge time.Duration) (cookie string, err error)
+	// Delete discards a session, e.g. its old ID after a regeneration.
+	Delete(ctx context.Context, id string) error
+}
+
+type Config struct {
+	// Name of the cookie, default "session".
+	Name  string
+	Store Store
+	// Path defaults to "/".
+	Path   string
+	Domain string
+	// MaxAge of the cookie and of the stored session, default 24 hours.
+	MaxAge time.Duration
+	Secure bool
+	// SameSite defaults to Lax. The cookie is always HttpOnly.
+	SameSite http.SameSite
+}
+
+// New loads the session of each request and makes it available with
+// Context.Session.
+func New(conf Config) engine.HandlerFunc {
+	if conf.Store == nil {
+		panic("sessions: a store is required")
+	}
+	if conf.Name == "" {
+		conf.Name = "session"
+	}
+	if conf.Path == "" {
+		conf.Path = "/"
+	}
+	if conf.MaxAge <= 0 {
+		conf.MaxAge = 24 * time.Hour
+	}
+	if conf.SameSite == 0 {
+		conf.SameSite = http.SameSiteLaxMode
+	}
+
+	return func(c *engine.Context) {
+		s := &session{c: c, conf: &conf, values: make(map[string]interface{})}
+		if cookie, err := c.Req.Cookie(conf.Name); err == nil {
+			id, values, err := conf.Store.Load(c, cookie.Value)
+			switch {
+			case err == nil:
+				s.id, s.values = id, values
+			case !errors.Is(err, ErrNotFound):
+				c.AbortWithStatusJSON(http.StatusInternalServerError, engine.H{"error": "session store unavailable"})
+				return
+			}
+		}
+		if s.id == "" {
+			s.id = newID()
+		}
+		c.Set(engine.SessionKey, s)
+		c.Next()
+	}
+}
+
+const flashesKey = "_flashes"
+
+type session struct {
+	c      *engine.Context
+	conf   *Config
+	id     string
+	oldIDs []string
+	values map[string]interface{}
+}
+
+func (s *session) ID() string {
+	return s.id
+}
+
+func (s *session) Get(key string) interface{} {
+	return s.values[key]
+}
+
+func (s *session) Set(key string, value interface{}) {
+	s.values[key] = value
+}
+
+func (s *session) Delete(key string) {
+	delete(s.values, key)
+}
+
+func (s *session) Clear() {
+	s.values = make(map[string]interface{})
+}
+
+func (s *session) Flash(value interface{}) {
+	flashes, _ := s.values[flashesKey].([]interface{})
+	s.values[flashesKey] = append(flashes, value)
+}
+
+func (s *session) Flashes() []interface{} {
+	flashes, _ := s.values[flashesKey].([]interface{})
+	delete(s.values, flashesKey)
+	return flashes
+}
+
+func (s *session) RegenerateID() {
+	s.oldIDs = append(s.oldIDs, s.id)
+	s.id = newID()
+}
+
+// Save stores the values and sets the cookie, replacing one set by an
+// earlier Save of the same request.
+func (s *session) Save() error {
+	for _, id := range s.oldIDs {
+		if err := s.conf.Store.Delete(s.c, id); err != nil {
+			return err
+		}
+	}
+	s.oldIDs = nil
+
+	value, err := s.conf.Store.Save(s.c, s.id, s.values, s.conf.MaxAge)
+	if err != nil {
+		return err
+	}
+
+	header := s.c.Res.Header()
+	cookies := header["Set-Cookie"][:0]
+	for _, cookie := range header["Set-Cookie"] {
+		if !strings.HasPrefix(cookie, s.conf.Name+"=") {
+			cookies = append(cookies, cookie)
+		}
+	}
+	header["Set-Cookie"] = cookies
+
+	http.SetCookie(s.c.Res, &http.Cookie{
+		Name:     s.conf.Name,
+		Value:    value,
+		Path:     s.conf.Path,
+		Domain:   s.conf.Domain,
+		MaxAge:   int(s.conf.MaxAge / time.Second),
+		Secure:   s.conf.Secure,
+		HttpOnly: true,
+		SameSite: s.conf.SameSite,
+	})
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
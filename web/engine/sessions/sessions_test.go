@@ -0,0 +1,163 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"web/engine"
+)
+
+type client struct {
+	e      *engine.Engine
+	cookie *http.Cookie
+}
+
+func (cl *client) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if cl.cookie != nil {
+		req.AddCookie(cl.cookie)
+	}
+	w := httptest.NewRecorder()
+	cl.e.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		cl.cookie = cookie
+	}
+	return w
+}
+
+func newEngine(store Store) *engine.Engine {
+	e := engine.New()
+	e.Use(New(Config{Store: store}))
+	e.Get("/login", func(c *engine.Context) {
+		s := c.Session()
+		s.RegenerateID()
+		s.Set("user", "geek")
+		s.Flash("welcome")
+		if err := s.Save(); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.String(http.StatusOK, s.ID())
+	})
+	e.Get("/me", func(c *engine.Context) {
+		s := c.Session()
+		flashes := s.Flashes()
+		s.Save()
+		c.String(http.StatusOK, "%v %v", s.Get("user"), flashes)
+	})
+	e.Get("/logout", func(c *engine.Context) {
+		s := c.Session()
+		s.Clear()
+		s.RegenerateID()
+		s.Save()
+	})
+	return e
+}
+
+func TestSessions(t *testing.T) {
+	for name, store := range map[string]Store{
+		"memory": NewMemoryStore(),
+		"cookie": NewCookieStore([]byte("0123456789abcdef0123456789abcdef")),
+	} {
+		cl := &client{e: newEngine(store)}
+		if w := cl.get("/me"); w.Body.String() != "<nil> []" {
+			t.Fatalf("%s: expect an empty session, got %q", name, w.Body.String())
+		}
+		anonymous := cl.cookie
+
+		w := cl.get("/login")
+		id := w.Body.String()
+		cookie := w.Result().Cookies()[0]
+		if !cookie.HttpOnly || cookie.Path != "/" || cookie.MaxAge != 86400 || cookie.SameSite != http.SameSiteLaxMode {
+			t.Fatalf("%s: unexpected cookie %+v", name, cookie)
+		}
+		if len(w.Header().Values("Set-Cookie")) != 1 {
+			t.Fatalf("%s: expect a single cookie, got %v", name, w.Header().Values("Set-Cookie"))
+		}
+
+		if w := cl.get("/me"); w.Body.String() != "geek [welcome]" {
+			t.Fatalf("%s: expect the user and the flash, got %q", name, w.Body.String())
+		}
+		if w := cl.get("/me"); w.Body.String() != "geek []" {
+			t.Fatalf("%s: flashes should be read once, got %q", name, w.Body.String())
+		}
+
+		cl.get("/logout")
+		if w := cl.get("/me"); w.Body.String() != "<nil> []" {
+			t.Fatalf("%s: expect a cleared session, got %q", name, w.Body.String())
+		}
+		if _, ok := store.(*MemoryStore); ok {
+			if _, _, err := store.Load(context.Background(), id); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("the old id should be discarded after a regeneration, got %v", err)
+			}
+			if _, _, err := store.Load(context.Background(), anonymous.Value); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("the anonymous id should be discarded on login, got %v", err)
+			}
+		}
+	}
+}
+
+func TestCookieStore(t *testing.T) {
+	oldKey := []byte("0123456789abcdef")
+	newKey := []byte("fedcba9876543210")
+	ctx := context.Background()
+
+	cookie, err := NewCookieStore(oldKey).Save(ctx, "id", map[string]interface{}{"user": "geek"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := NewCookieStore(newKey, oldKey)
+	id, values, err := rotated.Load(ctx, cookie)
+	if err != nil || id != "id" || values["user"] != "geek" {
+		t.Fatalf("cookies of the old key should still load, got %q %v %v", id, values, err)
+	}
+	if _, _, err := NewCookieStore(newKey).Load(ctx, cookie); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cookies of a dropped key should not load, got %v", err)
+	}
+
+	tampered := []byte(cookie)
+	tampered[len(tampered)/2] ^= 1
+	if _, _, err := rotated.Load(ctx, string(tampered)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("tampered cookies should not load, got %v", err)
+	}
+
+	expired, _ := rotated.Save(ctx, "id", nil, -time.Second)
+	if _, _, err := rotated.Load(ctx, expired); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expired cookies should not load, got %v", err)
+	}
+
+	if _, err := rotated.Save(ctx, "id", map[string]interface{}{"big": strings.Repeat("x", 5000)}, time.Hour); err != ErrCookieTooLarge {
+		t.Fatalf("expect ErrCookieTooLarge, got %v", err)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	store.Save(ctx, "expired", map[string]interface{}{"user": "geek"}, -time.Second)
+	if _, _, err := store.Load(ctx, "expired"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expired sessions should not load, got %v", err)
+	}
+
+	store.lastSweep = time.Time{}
+	store.Save(ctx, "fresh", nil, time.Hour)
+	if store.Len() != 1 {
+		t.Fatalf("expired sessions should be swept, %d left", store.Len())
+	}
+}
+
+func TestNoMiddleware(t *testing.T) {
+	e := engine.New()
+	e.Get("/", func(c *engine.Context) {
+		if c.Session() != nil {
+			t.Errorf("expect no session without the middleware")
+		}
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type StaticOptions struct {
+	// Index is served for directory requests, default "index.html".
+	Index string
+	// Listing renders the directory content when there is no index file.
+	Listing bool
+}
+
+type StaticOption func(*StaticOptions)
+
+func StaticIndex(name string) StaticOption {
+	return func(o *StaticOptions) {
+		o.Index = name
+	}
+}
+
+func StaticListing(enabled bool) StaticOption {
+	return func(o *StaticOptions) {
+		o.Listing = enabled
+	}
+}
+
+// Static serves the files under the root directory, e.g.
+// group.Static("/assets", "./public").
+func (group *RouterGroup) Static(relativePath string, root string, options ...StaticOption) {
+	group.StaticFS(relativePath, os.DirFS(root), options...)
+}
+
+// StaticFS serves fsys, which may be an embed.FS, under relativePath.
+func (group *RouterGroup) StaticFS(relativePath string, fsys fs.FS, options ...StaticOption) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("engine: url parameters can not be used when serving a static folder")
+	}
+
+	opts := StaticOptions{Index: "index.html", Listing: true}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	handler := func(c *Context) {
+		serveFS(c, fsys, c.Param("filepath"), opts)
+	}
+	pattern := path.Join(relativePath, "/*filepath")
+	group.Get(pattern, handler)
+	group.Head(pattern, handler)
+}
+
+// StaticFile serves a single file from the local file system.
+func (group *RouterGroup) StaticFile(relativePath string, file string) {
+	group.StaticFileFS(relativePath, filepath.Base(file), os.DirFS(filepath.Dir(file)))
+}
+
+func (group *RouterGroup) StaticFileFS(relativePath string, name string, fsys fs.FS) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("engine: url parameters can not be used when serving a static file")
+	}
+
+	handler := func(c *Context) {
+		serveFS(c, fsys, name, StaticOptions{})
+	}
+	group.Get(relativePath, handler)
+	group.Head(relativePath, handler)
+}
+
+func serveFS(c *Context, fsys fs.FS, name string, opts StaticOptions) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		staticError(c, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		staticError(c, err)
+		return
+	}
+
+	if info.IsDir() {
+		if !strings.HasSuffix(c.Req.URL.Path, "/") {
+			target := *c.Req.URL
+			target.Path += "/"
+			http.Redirect(c.Res, c.Req, target.String(), http.StatusMovedPermanently)
+			return
+		}
+		if opts.Index != "" {
+			if index, err := fsys.Open(path.Join(name, opts.Index)); err == nil {
+				defer index.Close()
+				if indexInfo, err := index.Stat(); err == nil && !indexInfo.IsDir() {
+					serveContent(c, index, indexInfo)
+					return
+				}
+			}
+		}
+		if !opts.Listing {
+			c.String(http.StatusNotFound, "404 page not found")
+			return
+		}
+		listDir(c, fsys, name)
+		return
+	}
+
+	serveContent(c, f, info)
+}
+
+// serveContent sets an ETag before handing over to http.ServeContent, which
+// answers Range, If-Range, If-None-Match and If-Modified-Since requests.
+func serveContent(c *Context, f fs.File, info fs.FileInfo) {
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			staticError(c, err)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if info.ModTime().IsZero() {
+		// embed.FS has no modification time, so hash the content instead.
+		hash := sha256.New()
+		if _, err := io.Copy(hash, content); err != nil {
+			staticError(c, err)
+			return
+		}
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			staticError(c, err)
+			return
+		}
+		c.SetHeader("ETag", `"`+hex.EncodeToString(hash.Sum(nil)[:16])+`"`)
+	} else {
+		c.SetHeader("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	}
+
+	http.ServeContent(c.Res, c.Req, info.Name(), info.ModTime(), content)
+}
+
+func listDir(c *Context, fsys fs.FS, name string) {
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		staticError(c, err)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var buf strings.Builder
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		link := url.URL{Path: entryName}
+		fmt.Fprintf(&buf, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(entryName))
+	}
+	buf.WriteString("</pre>\n")
+
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if c.Method != http.MethodHead {
+		c.Res.Write([]byte(buf.String()))
+	}
+}
+
+func staticError(c *Context, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.String(http.StatusNotFound, "404 page not found")
+	case errors.Is(err, fs.ErrPermission):
+		c.String(http.StatusForbidden, "403 Forbidden")
+	default:
+		c.String(http.StatusInternalServerError, "500 Internal Server Error")
+	}
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatic(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "css/main.css"), "body{}")
+	writeFile(t, filepath.Join(dir, "docs/index.html"), "<h1>docs</h1>")
+
+	e := New()
+	var hits int
+	assets := e.Group("/assets")
+	assets.Use(func(c *Context) {
+		hits++
+		c.Next()
+	})
+	assets.Static("/", dir)
+	e.Static("/private", dir, StaticListing(false))
+
+	w := get(e, "/assets/css/main.css")
+	if w.Code != http.StatusOK || w.Body.String() != "body{}" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/css") {
+		t.Fatalf("unexpected file response %d %s", w.Code, w.Body.String())
+	}
+	if hits != 1 {
+		t.Fatalf("static files should pass through group middleware")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/css/main.css", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("matching ETag should respond 304, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/assets/css/main.css", nil)
+	req.Header.Set("Range", "bytes=0-3")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "body" {
+		t.Fatalf("range request should respond 206 body, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := get(e, "/assets/docs/"); w.Body.String() != "<h1>docs</h1>" {
+		t.Fatalf("directory should serve index file, got %s", w.Body.String())
+	}
+	if w := get(e, "/assets/"); !strings.Contains(w.Body.String(), `<a href="css/">css/</a>`) {
+		t.Fatalf("directory should be listed, got %s", w.Body.String())
+	}
+	if w := get(e, "/private/"); w.Code != http.StatusNotFound {
+		t.Fatalf("disabled listing should respond 404, got %d", w.Code)
+	}
+	if w := get(e, "/assets/missing.js"); w.Code != http.StatusNotFound {
+		t.Fatalf("missing file should respond 404, got %d", w.Code)
+	}
+}
+
+func TestStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{"app.js": {Data: []byte("run()")}}
+
+	e := New()
+	e.StaticFS("/js", fsys)
+	e.StaticFileFS("/favicon.js", "app.js", fsys)
+
+	for _, path := range []string{"/js/app.js", "/favicon.js"} {
+		w := get(e, path)
+		if w.Body.String() != "run()" || w.Header().Get("ETag") == "" {
+			t.Fatalf("%s should be served with an ETag, got %s", path, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package engine
+
+import "strings"
+
+type Param struct {
+	Key   string
+	Value string
+}
+
+type Params []Param
+
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
+}
+
+// node is one path segment of the routing trie. Static children are matched
+// first, then the :param child, then the *catchall child.
+type node struct {
+	static   map[string]*node
+	param    *node
+	catchAll *node
+	name     string // param or catchall name, without the leading ':' or '*'
+	route    *route
+}
+
+func newNode() *node {
+	return &node{static: make(map[string]*node)}
+}
+
+func (n *node) insert(path string, r *route) {
+	if path == "" || path[0] != '/' {
+		panic("engine: path must begin with '/' in route " + path)
+	}
+
+	current := n
+	parts := strings.Split(path[1:], "/")
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, ":"):
+			name := part[1:]
+			if name == "" {
+				panic("engine: empty param name in route " + path)
+			}
+			if current.param == nil {
+				current.param = newNode()
+				current.param.name = name
+			} else if current.param.name != name {
+				panic("engine: param ':" + name + "' conflicts with ':" + current.param.name + "' in route " + path)
+			}
+			current = current.param
+		case strings.HasPrefix(part, "*"):
+			name := part[1:]
+			if name == "" {
+				panic("engine: empty catch-all name in route " + path)
+			}
+			if i != len(parts)-1 {
+				panic("engine: catch-all must be the last segment in route " + path)
+			}
+			if current.catchAll == nil {
+				current.catchAll = newNode()
+				current.catchAll.name = name
+			} else if current.catchAll.name != name {
+				panic("engine: catch-all '*" + name + "' conflicts with '*" + current.catchAll.name + "' in route " + path)
+			}
+			current = current.catchAll
+		default:
+			child, ok := current.static[part]
+			if !ok {
+				child = newNode()
+				current.static[part] = child
+			}
+			current = child
+		}
+	}
+
+	if current.route != nil {
+		panic("engine: route " + path + " is already registered")
+	}
+	current.route = r
+}
+
+// search matches path, which must begin with '/', and appends any captured
+// params. It returns nil when no route matches.
+func (n *node) search(path string, params *Params) *route {
+	if path == "" || path[0] != '/' {
+		return nil
+	}
+	if found := n.searchSegment(path[1:], params); found != nil {
+		return found.route
+	}
+	return nil
+}
+
+func (n *node) searchSegment(path string, params *Params) *node {
+	segment, rest, more := path, "", false
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		segment, rest, more = path[:i], path[i+1:], true
+	}
+
+	if child, ok := n.static[segment]; ok {
+		if found := child.next(rest, more, params); found != nil {
+			return found
+		}
+	}
+
+	if n.param != nil && segment != "" {
+		*params = append(*params, Param{Key: n.param.name, Value: segment})
+		if found := n.param.next(rest, more, params); found != nil {
+			return found
+		}
+		*params = (*params)[:len(*params)-1]
+	}
+
+	if n.catchAll != nil && n.catchAll.route != nil {
+		*params = append(*params, Param{Key: n.catchAll.name, Value: "/" + path})
+		return n.catchAll
+	}
+
+	return nil
+}
+
+func (n *node) next(rest string, more bool, params *Params) *node {
+	if !more {
+		if n.route != nil {
+			return n
+		}
+		return nil
+	}
+	return n.searchSegment(rest, params)
+}
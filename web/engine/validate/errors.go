@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes one field that failed one rule.
+type FieldError struct {
+	Field     string      `json:"field"`
+	Namespace string      `json:"namespace"`
+	Tag       string      `json:"tag"`
+	Param     string      `json:"param,omitempty"`
+	Value     interface{} `json:"-"`
+}
+
+func (e FieldError) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("validate: field %s failed on %s=%s", e.Namespace, e.Tag, e.Param)
+	}
+	return fmt.Sprintf("validate: field %s failed on %s", e.Namespace, e.Tag)
+}
+
+// ValidationErrors lists every failing field of a struct.
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return strings.Join(messages, "; ")
+}
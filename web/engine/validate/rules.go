@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var builtins = map[string]Func{
+	"required": required,
+	"min":      func(f Field) bool { return compare(f, func(n, p float64) bool { return n >= p }) },
+	"max":      func(f Field) bool { return compare(f, func(n, p float64) bool { return n <= p }) },
+	"len":      func(f Field) bool { return compare(f, func(n, p float64) bool { return n == p }) },
+	"oneof":    oneOf,
+	"email":    email,
+	"regexp":   matchRegexp,
+	"eqfield":  eqField,
+	"nefield":  func(f Field) bool { return !eqField(f) },
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+// parsers turn the params of the builtin rules into the values they compare
+// against, once per struct type rather than on every validation.
+var parsers = map[string]func(param string) (interface{}, error){
+	"min":    parseNumber,
+	"max":    parseNumber,
+	"len":    parseNumber,
+	"regexp": func(param string) (interface{}, error) { return regexp.Compile(param) },
+}
+
+func parseNumber(param string) (interface{}, error) {
+	return strconv.ParseFloat(param, 64)
+}
+
+func required(f Field) bool {
+	switch f.Value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !f.Value.IsNil()
+	case reflect.Slice, reflect.Map:
+		return f.Value.Len() > 0
+	default:
+		return f.Value.IsValid() && !f.Value.IsZero()
+	}
+}
+
+// compare checks numbers by value and strings, slices and maps by length.
+func compare(f Field, ok func(n, p float64) bool) bool {
+	p, isNumber := f.arg.(float64)
+	if !isNumber {
+		return false
+	}
+
+	var n float64
+	switch f.Value.Kind() {
+	case reflect.String:
+		n = float64(utf8.RuneCountInString(f.Value.String()))
+	case reflect.Slice, reflect.Map, reflect.Array:
+		n = float64(f.Value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(f.Value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(f.Value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = f.Value.Float()
+	default:
+		return false
+	}
+	return ok(n, p)
+}
+
+// oneOf accepts a space separated list of values.
+func oneOf(f Field) bool {
+	value := fmt.Sprint(f.Value.Interface())
+	for _, option := range strings.Fields(f.Param) {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+func email(f Field) bool {
+	return f.Value.Kind() == reflect.String && emailRegexp.MatchString(f.Value.String())
+}
+
+func matchRegexp(f Field) bool {
+	re, ok := f.arg.(*regexp.Regexp)
+	return ok && f.Value.Kind() == reflect.String && re.MatchString(f.Value.String())
+}
+
+func eqField(f Field) bool {
+	other := f.Parent.FieldByName(f.Param)
+	if !other.IsValid() {
+		return false
+	}
+	for other.Kind() == reflect.Ptr && !other.IsNil() {
+		other = other.Elem()
+	}
+	if !other.CanInterface() || !f.Value.CanInterface() {
+		return false
+	}
+	return reflect.DeepEqual(f.Value.Interface(), other.Interface())
+}
@@ -0,0 +1,233 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TagName is the struct tag holding the rules, e.g. `binding:"required,min=3"`.
+// A comma inside a rule param must be written as 0x2C.
+const TagName = "binding"
+
+// Field is passed to a rule function.
+type Field struct {
+	Name   string
+	Value  reflect.Value
+	Param  string
+	Parent reflect.Value // the struct holding the field, for cross-field rules
+
+	arg interface{} // Param as parsed once by the builtin rule, if any
+}
+
+// Func reports whether the field passes the rule.
+type Func func(f Field) bool
+
+type rule struct {
+	tag   string
+	param string
+	arg   interface{}
+	fn    Func
+}
+
+type fieldRules struct {
+	index     int
+	name      string
+	omitempty bool
+	rules     []rule
+}
+
+type Validator struct {
+	mu      sync.RWMutex
+	funcs   map[string]Func
+	parsers map[string]func(param string) (interface{}, error)
+	cache   sync.Map // reflect.Type -> []fieldRules
+}
+
+func New() *Validator {
+	v := &Validator{
+		funcs:   make(map[string]Func),
+		parsers: make(map[string]func(string) (interface{}, error)),
+	}
+	for name, fn := range builtins {
+		v.funcs[name] = fn
+	}
+	for name, parse := range parsers {
+		v.parsers[name] = parse
+	}
+	return v
+}
+
+// Default is used by the package level functions and by the binding package.
+var Default = New()
+
+func Register(name string, fn Func) {
+	Default.Register(name, fn)
+}
+
+func Struct(obj interface{}) error {
+	return Default.Struct(obj)
+}
+
+// Register adds or replaces the rule called name.
+func (v *Validator) Register(name string, fn Func) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.funcs[name] = fn
+	delete(v.parsers, name)
+	v.cache.Range(func(key, _ interface{}) bool {
+		v.cache.Delete(key)
+		return true
+	})
+}
+
+// ValidateStruct implements binding.StructValidator.
+func (v *Validator) ValidateStruct(obj interface{}) error {
+	return v.Struct(obj)
+}
+
+// Struct validates obj, which may be a struct, a pointer to one or a slice
+// of them. It returns ValidationErrors listing every failing field.
+func (v *Validator) Struct(obj interface{}) error {
+	var errs ValidationErrors
+	if err := v.validate(reflect.ValueOf(obj), "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *Validator) validate(value reflect.Value, namespace string, errs *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.validate(value.Index(i), fmt.Sprintf("%s[%d]", namespace, i), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			if err := v.validate(iter.Value(), fmt.Sprintf("%s[%v]", namespace, iter.Key()), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return v.validateStruct(value, namespace, errs)
+	}
+	return nil
+}
+
+func (v *Validator) validateStruct(value reflect.Value, namespace string, errs *ValidationErrors) error {
+	fields, err := v.rulesOf(value.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, fr := range fields {
+		fv := value.Field(fr.index)
+		ns := fr.name
+		if namespace != "" {
+			ns = namespace + "." + fr.name
+		}
+
+		if !(fr.omitempty && fv.IsZero()) {
+			for _, r := range fr.rules {
+				target := fv
+				if r.tag != "required" {
+					for target.Kind() == reflect.Ptr && !target.IsNil() {
+						target = target.Elem()
+					}
+					if target.Kind() == reflect.Ptr {
+						continue
+					}
+				}
+				if !r.fn(Field{Name: fr.name, Value: target, Param: r.param, Parent: value, arg: r.arg}) {
+					*errs = append(*errs, FieldError{
+						Field:     fr.name,
+						Namespace: ns,
+						Tag:       r.tag,
+						Param:     r.param,
+						Value:     valueOf(fv),
+					})
+				}
+			}
+		}
+
+		if err := v.validate(fv, ns, errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Validator) rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]fieldRules), nil
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var fields []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		fr := fieldRules{index: i, name: sf.Name}
+		for _, part := range strings.Split(tag, ",") {
+			if part == "" {
+				continue
+			}
+			if part == "omitempty" {
+				fr.omitempty = true
+				continue
+			}
+			name, param, _ := strings.Cut(part, "=")
+			fn, ok := v.funcs[name]
+			if !ok {
+				return nil, errors.New("validate: undefined rule " + strconv.Quote(name) + " on field " + sf.Name)
+			}
+			param = strings.ReplaceAll(param, "0x2C", ",")
+			r := rule{tag: name, param: param, fn: fn}
+			if parse, ok := v.parsers[name]; ok {
+				arg, err := parse(param)
+				if err != nil {
+					return nil, fmt.Errorf("validate: invalid param %q for rule %s on field %s: %w", param, name, sf.Name, err)
+				}
+				r.arg = arg
+			}
+			fr.rules = append(fr.rules, r)
+		}
+		fields = append(fields, fr)
+	}
+
+	v.cache.Store(t, fields)
+	return fields, nil
+}
+
+func valueOf(value reflect.Value) interface{} {
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	return nil
+}
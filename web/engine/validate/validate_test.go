@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	City string `binding:"required"`
+}
+
+type signup struct {
+	Name     string `binding:"required,min=3,max=8"`
+	Email    string `binding:"required,email"`
+	Role     string `binding:"oneof=admin user"`
+	Code     string `binding:"len=4,regexp=^[0-9]+$"`
+	Password string `binding:"required"`
+	Confirm  string `binding:"eqfield=Password"`
+	Age      *int   `binding:"omitempty,min=18"`
+	Nickname string `binding:"omitempty,min=2"`
+	Address  address
+	Tags     []address `binding:"max=2"`
+}
+
+func TestStruct(t *testing.T) {
+	ok := signup{
+		Name: "geek", Email: "geek@example.com", Role: "user", Code: "1234",
+		Password: "secret", Confirm: "secret", Address: address{City: "shanghai"},
+	}
+	if err := Struct(&ok); err != nil {
+		t.Fatalf("valid struct should pass, got %v", err)
+	}
+
+	age := 3
+	bad := signup{
+		Name: "ge", Email: "geek", Role: "root", Code: "12a4",
+		Password: "secret", Confirm: "other", Age: &age,
+		Tags: []address{{City: "a"}, {}, {City: "c"}},
+	}
+	err := Struct(bad)
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expect ValidationErrors, got %v", err)
+	}
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.Namespace+":"+e.Tag)
+	}
+	expect := []string{
+		"Name:min", "Email:email", "Role:oneof", "Code:regexp", "Confirm:eqfield",
+		"Age:min", "Address.City:required", "Tags:max", "Tags[1].City:required",
+	}
+	if !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	v := New()
+	v.Register("lower", func(f Field) bool {
+		return f.Value.String() == strings.ToLower(f.Value.String())
+	})
+
+	type user struct {
+		Name string `binding:"lower"`
+	}
+	if err := v.Struct(user{Name: "geek"}); err != nil {
+		t.Fatalf("custom rule should pass, got %v", err)
+	}
+	if err := v.Struct(user{Name: "Geek"}); err == nil {
+		t.Fatalf("custom rule should fail")
+	}
+
+	type unknown struct {
+		Name string `binding:"missing"`
+	}
+	if err := v.Struct(unknown{}); err == nil {
+		t.Fatalf("undefined rule should return an error")
+	}
+}
+
+func TestInvalidParam(t *testing.T) {
+	v := New()
+
+	type number struct {
+		Age int `binding:"min=abc"`
+	}
+	if err := v.Struct(number{Age: 3}); err == nil || !strings.Contains(err.Error(), "min") {
+		t.Fatalf("bad numeric param should return an error, got %v", err)
+	}
+
+	type pattern struct {
+		Code string `binding:"regexp=[a-"`
+	}
+	if err := v.Struct(pattern{Code: "x"}); err == nil || !strings.Contains(err.Error(), "regexp") {
+		t.Fatalf("bad pattern should return an error, got %v", err)
+	}
+}
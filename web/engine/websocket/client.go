@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// Dialer opens client connections, mostly useful for tests and services
+// talking to each other.
+type Dialer struct {
+	Subprotocols      []string
+	EnableCompression bool
+	// ReadLimit is the maximum size of a message, default DefaultReadLimit.
+	ReadLimit int64
+	TLSConfig *tls.Config
+}
+
+var DefaultDialer = &Dialer{}
+
+// Dial connects to a ws:// or wss:// url with DefaultDialer.
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	return DefaultDialer.Dial(ctx, rawURL, header)
+}
+
+func (d *Dialer) Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secure bool
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme, secure = "https", true
+	default:
+		return nil, nil, errors.New("websocket: unsupported url scheme " + u.Scheme)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		if secure {
+			address = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			address = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secure {
+		config := d.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(netConn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			netConn.Close()
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+
+	// abort the handshake when ctx is done
+	stop := context.AfterFunc(ctx, func() { netConn.Close() })
+	defer stop()
+
+	c, res, err := d.handshake(netConn, u, header)
+	if err != nil {
+		netConn.Close()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, res, err
+	}
+	return c, res, nil
+}
+
+func (d *Dialer) handshake(netConn net.Conn, u *url.URL, header http.Header) (*Conn, *http.Response, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+	if d.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	}
+
+	if err := req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContains(res.Header, "Upgrade", "websocket") ||
+		!headerContains(res.Header, "Connection", "upgrade") ||
+		res.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, res, ErrBadHandshake
+	}
+
+	c := newConn(netConn, br, false)
+	c.subproto = res.Header.Get("Sec-WebSocket-Protocol")
+	c.SetReadLimit(d.ReadLimit)
+	for _, ext := range parseExtensions(res.Header) {
+		if ext.name == "permessage-deflate" {
+			if !d.EnableCompression {
+				return nil, res, ErrBadHandshake
+			}
+			c.compress, c.writeCompression = true, true
+		}
+	}
+	return c, res, nil
+}
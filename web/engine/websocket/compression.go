@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// permessage-deflate (RFC 7692), always without context takeover so that
+// every message is compressed on its own.
+
+const defaultCompressionLevel = flate.BestSpeed
+
+var errBadCompression = errors.New("websocket: invalid compressed message")
+
+// deflateTail is removed from compressed messages and added back before
+// decompressing (RFC 7692 section 7.2.1), followed by an empty final block.
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+func compressMessage(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail[:4]), nil
+}
+
+func decompressMessage(data []byte, limit int64) ([]byte, error) {
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail)))
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, errBadCompression
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrReadLimit
+	}
+	return out, nil
+}
+
+const deflateResponse = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+// acceptDeflate reports whether one of the offered extensions is a
+// permessage-deflate we can honour. Windows smaller than the default can
+// not be produced by compress/flate, so such offers are declined.
+func acceptDeflate(header http.Header) bool {
+	for _, offer := range parseExtensions(header) {
+		if offer.name != "permessage-deflate" {
+			continue
+		}
+		ok := true
+		for key, value := range offer.params {
+			switch key {
+			case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+			case "server_max_window_bits":
+				ok = ok && value == "15"
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+type extension struct {
+	name   string
+	params map[string]string
+}
+
+func parseExtensions(header http.Header) []extension {
+	var extensions []extension
+	for _, value := range header.Values("Sec-WebSocket-Extensions") {
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(item, ";")
+			ext := extension{
+				name:   strings.ToLower(strings.TrimSpace(parts[0])),
+				params: make(map[string]string),
+			}
+			if ext.name == "" {
+				continue
+			}
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				ext.params[strings.ToLower(key)] = strings.Trim(value, `"`)
+			}
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
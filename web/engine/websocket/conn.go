@@ -0,0 +1,479 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Message types, which are also the frame opcodes (RFC 6455 section 5.2).
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+const (
+	maxControlPayload = 125
+	// DefaultReadLimit is the message size limit of connections whose
+	// Upgrader or Dialer sets none.
+	DefaultReadLimit = 16 << 20
+	// payloads are read in chunks, so that a frame header claiming a huge
+	// length does not allocate it up front
+	readChunkSize = 64 << 10
+)
+
+var (
+	ErrReadLimit   = errors.New("websocket: message exceeds the read limit")
+	ErrCloseSent   = errors.New("websocket: close frame already sent")
+	errBadOpcode   = errors.New("websocket: unknown opcode")
+	errBadMask     = errors.New("websocket: bad frame masking")
+	errBadControl  = errors.New("websocket: bad control frame")
+	errBadSequence = errors.New("websocket: bad fragment sequence")
+	errBadRSV      = errors.New("websocket: unexpected reserved bits")
+	errBadUTF8     = errors.New("websocket: invalid utf-8 in text message")
+	errBadClose    = errors.New("websocket: invalid close payload")
+	errBadLength   = errors.New("websocket: invalid payload length")
+)
+
+// CloseError is returned by ReadMessage once the peer sent a close frame.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return "websocket: close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+// IsCloseError reports whether err is a CloseError with one of codes.
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a WebSocket connection. It supports one concurrent reader and any
+// number of concurrent writers.
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isServer bool
+	subproto string
+
+	// read side, owned by the reader
+	readLimit   int64
+	readErr     error
+	pingHandler func(data string) error
+	pongHandler func(data string) error
+
+	// write side
+	mu               sync.Mutex
+	closeSent        bool
+	compress         bool // permessage-deflate was negotiated
+	writeCompression bool
+	compressionLevel int
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:             conn,
+		br:               br,
+		isServer:         isServer,
+		readLimit:        DefaultReadLimit,
+		compressionLevel: defaultCompressionLevel,
+	}
+	c.pingHandler = func(data string) error {
+		err := c.WriteMessage(PongMessage, []byte(data))
+		if errors.Is(err, ErrCloseSent) {
+			return nil
+		}
+		return err
+	}
+	c.pongHandler = func(string) error { return nil }
+	return c
+}
+
+// Subprotocol returns the negotiated subprotocol, if any.
+func (c *Conn) Subprotocol() string {
+	return c.subproto
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadLimit sets the maximum size of a message, after decompression.
+// A bigger message closes the connection with CloseMessageTooBig. A limit
+// of 0 or less restores DefaultReadLimit.
+func (c *Conn) SetReadLimit(limit int64) {
+	if limit <= 0 {
+		limit = DefaultReadLimit
+	}
+	c.readLimit = limit
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetPingHandler replaces the default handler, which answers with a pong.
+// Handlers run on the reading goroutine, from ReadMessage.
+func (c *Conn) SetPingHandler(h func(data string) error) {
+	c.pingHandler = h
+}
+
+func (c *Conn) SetPongHandler(h func(data string) error) {
+	c.pongHandler = h
+}
+
+// EnableWriteCompression turns compression of written messages on or off
+// when permessage-deflate was negotiated.
+func (c *Conn) EnableWriteCompression(enable bool) {
+	c.writeCompression = enable
+}
+
+// Close closes the network connection without a close frame, see
+// WriteClose for the closing handshake.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// WriteClose sends a close frame. The peer answers with its own close frame,
+// which ReadMessage returns as a CloseError.
+func (c *Conn) WriteClose(code int, text string) error {
+	return c.WriteMessage(CloseMessage, formatClose(code, text))
+}
+
+// WriteMessage writes data as a single frame. Control messages are limited
+// to 125 bytes and nothing can be written after a close frame.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+		if c.compress && c.writeCompression {
+			compressed, err := compressMessage(data, c.compressionLevel)
+			if err != nil {
+				return err
+			}
+			return c.writeFrame(true, true, messageType, compressed)
+		}
+	case CloseMessage, PingMessage, PongMessage:
+		if len(data) > maxControlPayload {
+			return errBadControl
+		}
+	default:
+		return errBadOpcode
+	}
+	return c.writeFrame(true, false, messageType, data)
+}
+
+func (c *Conn) writeFrame(fin bool, rsv1 bool, opcode int, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		c.closeSent = true
+	}
+
+	header := make([]byte, 0, 14)
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	if rsv1 {
+		b0 |= 0x40
+	}
+	header = append(header, b0)
+
+	var b1 byte
+	if !c.isServer {
+		b1 = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, b1|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, b1|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, b1|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if !c.isServer {
+		// clients mask every frame with a fresh key (section 5.3)
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		masked := make([]byte, len(payload))
+		copy(masked, payload)
+		maskBytes(key, masked)
+		payload = masked
+	}
+
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+type frame struct {
+	fin     bool
+	rsv1    bool
+	opcode  int
+	payload []byte
+}
+
+func (c *Conn) readFrame(remaining int64) (*frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return nil, err
+	}
+
+	f := &frame{
+		fin:    head[0]&0x80 != 0,
+		rsv1:   head[0]&0x40 != 0,
+		opcode: int(head[0] & 0x0F),
+	}
+	if head[0]&0x30 != 0 {
+		return nil, errBadRSV
+	}
+	masked := head[1]&0x80 != 0
+	if masked != c.isServer {
+		return nil, errBadMask
+	}
+
+	length := int64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint64(ext[:])
+		if n > 1<<63-1 {
+			return nil, errBadLength
+		}
+		length = int64(n)
+	}
+
+	switch f.opcode {
+	case CloseMessage, PingMessage, PongMessage:
+		if !f.fin || f.rsv1 || length > maxControlPayload {
+			return nil, errBadControl
+		}
+	case TextMessage, BinaryMessage, continuationFrame:
+		if length > remaining {
+			return nil, ErrReadLimit
+		}
+	default:
+		return nil, errBadOpcode
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	f.payload = make([]byte, 0, min(length, readChunkSize))
+	for int64(len(f.payload)) < length {
+		start := len(f.payload)
+		n := int(min(length-int64(start), readChunkSize))
+		f.payload = slices.Grow(f.payload, n)[:start+n]
+		if _, err := io.ReadFull(c.br, f.payload[start:]); err != nil {
+			return nil, err
+		}
+	}
+	if masked {
+		maskBytes(key, f.payload)
+	}
+	return f, nil
+}
+
+// ReadMessage returns the next data message, reassembling fragments and
+// handling the control frames in between. After the peer closes, or on a
+// protocol error, it returns the same error forever.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	compressed := false
+	for {
+		f, err := c.readFrame(c.readLimit - int64(len(data)))
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := c.pingHandler(string(f.payload)); err != nil {
+				return 0, nil, c.fail(err)
+			}
+			continue
+		case PongMessage:
+			if err := c.pongHandler(string(f.payload)); err != nil {
+				return 0, nil, c.fail(err)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.fail(c.handleClose(f.payload))
+		case continuationFrame:
+			if messageType == 0 || f.rsv1 {
+				return 0, nil, c.fail(errBadSequence)
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, c.fail(errBadSequence)
+			}
+			if f.rsv1 && !c.compress {
+				return 0, nil, c.fail(errBadRSV)
+			}
+			messageType, compressed = f.opcode, f.rsv1
+		}
+
+		data = append(data, f.payload...)
+		if f.fin {
+			break
+		}
+	}
+
+	if compressed {
+		if data, err = decompressMessage(data, c.readLimit); err != nil {
+			return 0, nil, c.fail(err)
+		}
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, nil, c.fail(errBadUTF8)
+	}
+	return messageType, data, nil
+}
+
+// handleClose answers the peer's close frame with the same code.
+func (c *Conn) handleClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) == 1 {
+		return errBadClose
+	}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) || !utf8.Valid(payload[2:]) {
+			return errBadClose
+		}
+	}
+
+	var reply []byte
+	if closeErr.Code != CloseNoStatusReceived {
+		reply = formatClose(closeErr.Code, "")
+	}
+	if err := c.WriteMessage(CloseMessage, reply); err != nil && !errors.Is(err, ErrCloseSent) {
+		return err
+	}
+	return closeErr
+}
+
+// fail records err as the final read error. Protocol errors are reported
+// to the peer with the matching close code before closing.
+func (c *Conn) fail(err error) error {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		code := 0
+		switch err {
+		case ErrReadLimit:
+			code = CloseMessageTooBig
+		case errBadUTF8:
+			code = CloseInvalidFramePayloadData
+		case errBadOpcode, errBadMask, errBadControl, errBadSequence, errBadRSV, errBadClose, errBadLength, errBadCompression:
+			code = CloseProtocolError
+		}
+		if code != 0 {
+			c.WriteClose(code, "")
+		}
+		c.conn.Close()
+	}
+	c.readErr = err
+	return err
+}
+
+func formatClose(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return nil
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return append(payload, text...)
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
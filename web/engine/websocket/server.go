@@ -0,0 +1,182 @@
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Upgrader turns HTTP requests into WebSocket connections (RFC 6455).
+type Upgrader struct {
+	// Subprotocols the server supports, in order of preference.
+	Subprotocols []string
+	// CheckOrigin rejects cross-origin requests when it returns false. The
+	// default accepts requests without an Origin header or whose origin
+	// host equals the Host header.
+	CheckOrigin func(r *http.Request) bool
+	// ReadLimit is the maximum size of a message, default DefaultReadLimit.
+	ReadLimit int64
+	// EnableCompression negotiates permessage-deflate when the client
+	// offers it.
+	EnableCompression bool
+	// CompressionLevel is a compress/flate level, default BestSpeed.
+	CompressionLevel int
+}
+
+// HandshakeError is returned when the request is not a valid upgrade.
+type HandshakeError struct {
+	Status  int
+	Message string
+}
+
+func (e HandshakeError) Error() string {
+	return "websocket: " + e.Message
+}
+
+// Upgrade completes the handshake and hijacks the connection. On failure it
+// responds with an HTTP error and returns a HandshakeError.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	if err := u.checkHandshake(r); err != nil {
+		var handshakeErr HandshakeError
+		if errors.As(err, &handshakeErr) {
+			if handshakeErr.Status == http.StatusUpgradeRequired {
+				w.Header().Set("Sec-WebSocket-Version", "13")
+			}
+			http.Error(w, http.StatusText(handshakeErr.Status), handshakeErr.Status)
+		}
+		return nil, err
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+	compress := u.EnableCompression && acceptDeflate(r.Header)
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// the server may have set deadlines for reading the request
+	netConn.SetDeadline(time.Time{})
+
+	var buf strings.Builder
+	buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	buf.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n")
+	if subprotocol != "" {
+		buf.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		buf.WriteString("Sec-WebSocket-Extensions: " + deflateResponse + "\r\n")
+	}
+	for key, values := range responseHeader {
+		if key == "Sec-Websocket-Protocol" || key == "Sec-Websocket-Extensions" {
+			continue
+		}
+		for _, value := range values {
+			buf.WriteString(key + ": " + strings.NewReplacer("\r", "", "\n", "").Replace(value) + "\r\n")
+		}
+	}
+	buf.WriteString("\r\n")
+
+	if _, err := netConn.Write([]byte(buf.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	// keep the buffered reader, the client may have sent frames right after
+	// the request
+	c := newConn(netConn, brw.Reader, true)
+	c.subproto = subprotocol
+	c.compress, c.writeCompression = compress, compress
+	c.SetReadLimit(u.ReadLimit)
+	if u.CompressionLevel != 0 {
+		c.compressionLevel = u.CompressionLevel
+	}
+	return c, nil
+}
+
+func (u *Upgrader) checkHandshake(r *http.Request) error {
+	switch {
+	case r.Method != http.MethodGet:
+		return HandshakeError{http.StatusMethodNotAllowed, "request method is not GET"}
+	case !headerContains(r.Header, "Connection", "upgrade"):
+		return HandshakeError{http.StatusBadRequest, "'upgrade' token not found in 'Connection' header"}
+	case !headerContains(r.Header, "Upgrade", "websocket"):
+		return HandshakeError{http.StatusBadRequest, "'websocket' token not found in 'Upgrade' header"}
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		return HandshakeError{http.StatusUpgradeRequired, "unsupported version"}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key"))
+	if err != nil || len(key) != 16 {
+		return HandshakeError{http.StatusBadRequest, "invalid 'Sec-WebSocket-Key' header"}
+	}
+
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return HandshakeError{http.StatusForbidden, "request origin not allowed"}
+	}
+	return nil
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	for _, requested := range headerTokens(r.Header, "Sec-WebSocket-Protocol") {
+		for _, supported := range u.Subprotocols {
+			if requested == supported {
+				return supported
+			}
+		}
+	}
+	return ""
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContains(header http.Header, name string, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newServer(t *testing.T, u *Upgrader, handle func(c *Conn)) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		handle(c)
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func echo(c *Conn) {
+	for {
+		messageType, data, err := c.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err := c.WriteMessage(messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+func dial(t *testing.T, d *Dialer, url string) *Conn {
+	t.Helper()
+	c, _, err := d.Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func expectMessage(t *testing.T, c *Conn, messageType int, data []byte) {
+	t.Helper()
+	gotType, got, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotType != messageType || !bytes.Equal(got, data) {
+		t.Fatalf("expect message %d %q, got %d %q", messageType, data, gotType, got)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	url := newServer(t, &Upgrader{Subprotocols: []string{"chat"}}, echo)
+	c := dial(t, &Dialer{Subprotocols: []string{"other", "chat"}}, url)
+	if c.Subprotocol() != "chat" {
+		t.Fatalf("expect subprotocol chat, got %q", c.Subprotocol())
+	}
+
+	big := bytes.Repeat([]byte("x"), 70000)
+	for _, tc := range []struct {
+		messageType int
+		data        []byte
+	}{
+		{TextMessage, []byte("hello")},
+		{BinaryMessage, []byte{0, 1, 2}},
+		{BinaryMessage, big},
+	} {
+		if err := c.WriteMessage(tc.messageType, tc.data); err != nil {
+			t.Fatal(err)
+		}
+		expectMessage(t, c, tc.messageType, tc.data)
+	}
+}
+
+func TestFragmentsAndPing(t *testing.T) {
+	var pong string
+	url := newServer(t, &Upgrader{}, echo)
+	c := dial(t, DefaultDialer, url)
+	c.SetPongHandler(func(data string) error {
+		pong = data
+		return nil
+	})
+
+	c.writeFrame(false, false, TextMessage, []byte("hel"))
+	c.WriteMessage(PingMessage, []byte("ping"))
+	c.writeFrame(false, false, continuationFrame, []byte("lo "))
+	c.writeFrame(true, false, continuationFrame, []byte("world"))
+	expectMessage(t, c, TextMessage, []byte("hello world"))
+	if pong != "ping" {
+		t.Fatalf("expect pong before the message, got %q", pong)
+	}
+}
+
+func TestClose(t *testing.T) {
+	url := newServer(t, &Upgrader{}, echo)
+	c := dial(t, DefaultDialer, url)
+
+	if err := c.WriteClose(CloseGoingAway, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("late")); !errors.Is(err, ErrCloseSent) {
+		t.Fatalf("expect ErrCloseSent, got %v", err)
+	}
+	_, _, err := c.ReadMessage()
+	if !IsCloseError(err, CloseGoingAway) {
+		t.Fatalf("expect the close code echoed, got %v", err)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	url := newServer(t, &Upgrader{ReadLimit: 8}, echo)
+	c := dial(t, DefaultDialer, url)
+
+	c.WriteMessage(TextMessage, []byte("0123456789"))
+	_, _, err := c.ReadMessage()
+	if !IsCloseError(err, CloseMessageTooBig) {
+		t.Fatalf("expect close 1009, got %v", err)
+	}
+}
+
+func TestDefaultReadLimit(t *testing.T) {
+	url := newServer(t, &Upgrader{}, echo)
+	c := dial(t, DefaultDialer, url)
+
+	// a masked binary frame header claiming a 1 TiB payload
+	header := []byte{0x82, 0x80 | 127}
+	header = binary.BigEndian.AppendUint64(header, 1<<40)
+	header = append(header, 1, 2, 3, 4)
+	if _, err := c.conn.Write(header); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+		t.Fatalf("expect close 1009 before reading the payload, got %v", err)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	url := newServer(t, &Upgrader{}, echo)
+
+	c := dial(t, DefaultDialer, url)
+	c.writeFrame(true, false, TextMessage, []byte{0xff, 0xfe})
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseInvalidFramePayloadData) {
+		t.Fatalf("expect close 1007 for invalid utf-8, got %v", err)
+	}
+
+	c = dial(t, DefaultDialer, url)
+	c.writeFrame(true, false, continuationFrame, []byte("x"))
+	if _, _, err := c.ReadMessage(); !IsCloseError(err, CloseProtocolError) {
+		t.Fatalf("expect close 1002 for a stray continuation, got %v", err)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	url := newServer(t, &Upgrader{EnableCompression: true}, echo)
+	c, res, err := (&Dialer{EnableCompression: true}).Dial(context.Background(), url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if !strings.HasPrefix(res.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate") {
+		t.Fatalf("expect permessage-deflate, got %q", res.Header.Get("Sec-WebSocket-Extensions"))
+	}
+
+	data := []byte(strings.Repeat("compress me ", 100))
+	c.WriteMessage(TextMessage, data)
+	expectMessage(t, c, TextMessage, data)
+	c.EnableWriteCompression(false)
+	c.WriteMessage(TextMessage, []byte("plain"))
+	expectMessage(t, c, TextMessage, []byte("plain"))
+}
+
+func TestBadHandshake(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		(&Upgrader{}).Upgrade(w, r, nil)
+	}))
+	defer srv.Close()
+
+	request := func(header map[string]string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+
+	if res := request(map[string]string{"Upgrade": "h2c"}); res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expect 400 without upgrade, got %d", res.StatusCode)
+	}
+	if res := request(map[string]string{"Sec-WebSocket-Version": "8"}); res.StatusCode != http.StatusUpgradeRequired ||
+		res.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Fatalf("expect 426 with the supported version, got %d", res.StatusCode)
+	}
+	if res := request(map[string]string{"Origin": "http://evil.example"}); res.StatusCode != http.StatusForbidden {
+		t.Fatalf("expect 403 for a foreign origin, got %d", res.StatusCode)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// example from RFC 6455 section 1.3
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %s", got)
+	}
+}
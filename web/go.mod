@@ -2,4 +2,10 @@ module web
 
 go 1.22.6
 
-require github.com/julienschmidt/httprouter v1.3.0 // indirect
+require (
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	google.golang.org/protobuf v1.36.5
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -1,74 +1,46 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"net"
 	"net/http"
-	"runtime"
-	"strings"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 	"web/engine"
+	"web/engine/csrf"
+	"web/engine/middleware"
 )
 
-func trace(message string) string {
-	var pcs [32]uintptr
-	n := runtime.Callers(3, pcs[:]) // skip first 3 caller
-
-	var str strings.Builder
-	str.WriteString(message + "\nTraceback:")
-	for _, pc := range pcs[:n] {
-		fn := runtime.FuncForPC(pc)
-		file, line := fn.FileLine(pc)
-		str.WriteString(fmt.Sprintf("\n\t%s:%d", file, line))
-	}
-	return str.String()
-}
-
-func Recovery() engine.HandlerFunc {
-	return func(c *engine.Context) {
-		defer func() {
-			if err := recover(); err != nil {
-				message := fmt.Sprintf("%s", err)
-				log.Printf("%s\n\n", trace(message))
-				c.Fail(http.StatusInternalServerError, "Internal Server Error")
-			}
-		}()
-
-		c.Next()
-	}
-}
-
-func Logger() engine.HandlerFunc {
-	return func(c *engine.Context) {
-		t := time.Now()
-		c.Next()
-		// Calculate resolution time
-		log.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
-	}
-}
-
 func main() {
 	e := engine.New()
+	// GEE_DEBUG=1 prints the route table on start and serves it to local
+	// clients
+	e.Debug = os.Getenv("GEE_DEBUG") != ""
 
-	e.Use(Recovery())
+	e.Use(middleware.Recovery())
+	e.LoadHTMLLayouts("templates/layouts/*.tmpl")
+	e.LoadHTMLGlob("templates/*.tmpl")
 	e.Get("/panic", func(c *engine.Context) {
 		names := []string{"hello"}
 		c.String(http.StatusOK, names[100])
 	})
 
 	e.Get("/hello", func(c *engine.Context) {
-		c.HTML(http.StatusOK, "hello world")
+		c.HTML(http.StatusOK, "hello.tmpl", "world")
 	})
 
 	e.Get("/blogs/:id", func(c *engine.Context) {
-		c.HTML(http.StatusOK, "blog:"+c.Param("id"))
+		c.HTML(http.StatusOK, "blog.tmpl", engine.H{"id": c.Param("id")})
 	})
 
 	v1 := e.Group("/v1")
-	v1.Use(Logger())
+	v1.Use(middleware.Logger())
 	{
 		v1.Get("/", func(c *engine.Context) {
-			c.HTML(http.StatusOK, "v1 index")
+			c.HTML(http.StatusOK, "hello.tmpl", "v1 index")
 		})
 
 		v1.Get("/hello", func(c *engine.Context) {
@@ -77,17 +49,58 @@ func main() {
 	}
 
 	v2 := e.Group("/v2")
+	v2.Use(csrf.New(csrf.Config{}))
 	{
 		v2.Get("/hello/:name", func(c *engine.Context) {
 			c.String(http.StatusOK, "hello %s", c.Param("name"))
 		})
+		v2.Get("/login", func(c *engine.Context) {
+			c.HTML(http.StatusOK, "login.tmpl", engine.H{"csrfField": csrf.TemplateField(c)})
+		})
 		v2.Post("/login", func(c *engine.Context) {
+			var form struct {
+				Username string `form:"username" binding:"required"`
+				Password string `form:"password" binding:"required"`
+			}
+			if err := c.Bind(&form); err != nil {
+				return
+			}
 			c.JSON(http.StatusOK, engine.H{
-				"username": c.PostForm("username"),
-				"password": c.PostForm("password"),
+				"username": form.Username,
+				"password": form.Password,
 			})
 		})
 	}
 
-	e.Run(":3000")
+	if e.Debug {
+		debug := e.Group("/debug")
+		debug.Use(func(c *engine.Context) {
+			if ip := net.ParseIP(c.ClientIP()); ip == nil || !ip.IsLoopback() {
+				c.AbortWithStatus(http.StatusNotFound)
+				return
+			}
+			c.Next()
+		})
+		debug.Get("/routes", engine.DebugRoutes)
+	}
+
+	e.OnStart(func() {
+		log.Println("listening on :3000")
+	})
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		e.Shutdown(ctx)
+	}()
+
+	// Run only returns once Shutdown is over, so the process does not exit
+	// while requests are still draining
+	if err := e.Run(":3000"); err != nil {
+		log.Fatal(err)
+	}
 }